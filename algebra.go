@@ -0,0 +1,528 @@
+package redisstringset
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// UnionSlice returns the members found in either the receiver or the other
+// Set, each exactly once, without modifying either Set. When both Sets use the
+// same Redis client this is a single SUNION; otherwise both Sets are fetched
+// and merged client-side.
+func (s *Set) UnionSlice(other *Set) ([]string, error) {
+	return s.UnionSliceCtx(context.Background(), other)
+}
+
+// UnionSliceCtx is like UnionSlice but uses ctx for every Redis command.
+func (s *Set) UnionSliceCtx(ctx context.Context, other *Set) ([]string, error) {
+	if err := checkSets([]*Set{other}); err != nil {
+		return nil, s.fail(fmt.Errorf("computing union of %s: %w", s.key, err))
+	}
+	if s.serverSide(other) {
+		result, err := s.combineOthers(ctx, "union", s.redisClient.SUnion, []*Set{other})
+		if !isCrossSlot(err) {
+			return result, err
+		}
+	}
+	return s.combineLocally(ctx, "union", []*Set{other})
+}
+
+// IntersectSlice returns the members found in the receiver and in every one of
+// the others, without modifying any Set. With no others it returns the
+// receiver's members; if any Set is empty the result is empty. When all Sets
+// use the receiver's Redis client this is a single SINTER; otherwise the Sets
+// are fetched and intersected client-side.
+func (s *Set) IntersectSlice(others ...*Set) ([]string, error) {
+	return s.IntersectSliceCtx(context.Background(), others...)
+}
+
+// IntersectSliceCtx is like IntersectSlice but uses ctx for every Redis command.
+func (s *Set) IntersectSliceCtx(ctx context.Context, others ...*Set) ([]string, error) {
+	if err := checkSets(others); err != nil {
+		return nil, s.fail(fmt.Errorf("computing intersection of %s: %w", s.key, err))
+	}
+	if s.serverSide(others...) {
+		result, err := s.combineOthers(ctx, "intersection", s.redisClient.SInter, others)
+		if !isCrossSlot(err) {
+			return result, err
+		}
+	}
+	return s.combineLocally(ctx, "intersection", others)
+}
+
+// DiffSlice returns the members of the receiver that are not in the other Set,
+// without modifying either Set; other.DiffSlice(s) gives the reverse
+// direction. When both Sets use the same Redis client this is a single SDIFF;
+// otherwise both Sets are fetched and compared client-side.
+func (s *Set) DiffSlice(other *Set) ([]string, error) {
+	return s.DiffSliceCtx(context.Background(), other)
+}
+
+// DiffSliceCtx is like DiffSlice but uses ctx for every Redis command.
+func (s *Set) DiffSliceCtx(ctx context.Context, other *Set) ([]string, error) {
+	if err := checkSets([]*Set{other}); err != nil {
+		return nil, s.fail(fmt.Errorf("computing difference of %s: %w", s.key, err))
+	}
+	if s.serverSide(other) {
+		result, err := s.combineOthers(ctx, "difference", s.redisClient.SDiff, []*Set{other})
+		if !isCrossSlot(err) {
+			return result, err
+		}
+	}
+	return s.combineLocally(ctx, "difference", []*Set{other})
+}
+
+// SymmetricDifference returns the members found in exactly one of the receiver
+// and the other Set, without modifying either Set. When both Sets use the same
+// Redis client this is two SDIFFs sent in a single pipelined round trip;
+// otherwise both Sets are fetched and compared client-side.
+func (s *Set) SymmetricDifference(other *Set) ([]string, error) {
+	return s.SymmetricDifferenceCtx(context.Background(), other)
+}
+
+// SymmetricDifferenceCtx is like SymmetricDifference but uses ctx for every
+// Redis command.
+func (s *Set) SymmetricDifferenceCtx(ctx context.Context, other *Set) ([]string, error) {
+	if err := checkSets([]*Set{other}); err != nil {
+		return nil, s.fail(fmt.Errorf("computing symmetric difference of %s: %w", s.key, err))
+	}
+	if s.serverSide(other) {
+		result, err := s.symmetricDifference(ctx, other.lockedKey())
+		if !isCrossSlot(err) {
+			return result, err
+		}
+	}
+
+	theirs, err := other.SliceCtx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ours, err := s.SliceCtx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[string]int, len(ours)+len(theirs))
+	for _, members := range [][]string{ours, theirs} {
+		for _, item := range members {
+			counts[item]++
+		}
+	}
+	result := []string{}
+	for _, members := range [][]string{ours, theirs} {
+		for _, item := range members {
+			if counts[item] == 1 {
+				result = append(result, item)
+			}
+		}
+	}
+	return result, nil
+}
+
+// symmetricDifference sends the two SDIFFs of SymmetricDifference. A
+// CROSSSLOT error is returned unwrapped so the caller can fall back.
+func (s *Set) symmetricDifference(ctx context.Context, otherKey string) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	var ours, theirs *redis.StringSliceCmd
+	_, err := s.redisClient.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		ours = pipe.SDiff(ctx, s.key, otherKey)
+		theirs = pipe.SDiff(ctx, otherKey, s.key)
+		return nil
+	})
+	if s.crossSlot(err) {
+		return nil, err
+	}
+	if err != nil {
+		s.logger.Printf("Error computing symmetric difference of %s: %v", s.key, err)
+		return nil, s.fail(fmt.Errorf("computing symmetric difference of %s: %w", s.key, classify(err)))
+	}
+	return append(append([]string{}, ours.Val()...), theirs.Val()...), nil
+}
+
+// IntersectCard returns the number of members found in both the receiver and
+// the other Set, counting no further than limit; a limit of 0 means no limit.
+// Neither Set is modified. When both Sets use the same Redis client this is a
+// single SINTERCARD, which stops as soon as limit is reached. Servers older
+// than Redis 7 lack SINTERCARD; the Set then falls back to SINTER for this and
+// every later call. Sets on different clients are intersected client-side.
+func (s *Set) IntersectCard(other *Set, limit int) (int, error) {
+	return s.IntersectCardCtx(context.Background(), other, limit)
+}
+
+// IntersectCardCtx is like IntersectCard but uses ctx for every Redis command.
+func (s *Set) IntersectCardCtx(ctx context.Context, other *Set, limit int) (int, error) {
+	if err := checkSets([]*Set{other}); err != nil {
+		return 0, s.fail(fmt.Errorf("counting intersection of %s: %w", s.key, err))
+	}
+	if limit < 0 {
+		return 0, s.fail(fmt.Errorf("counting intersection of %s: negative limit %d", s.key, limit))
+	}
+	if s.serverSide(other) {
+		n, err := s.intersectCard(ctx, other.lockedKey(), limit)
+		if !isCrossSlot(err) {
+			return n, err
+		}
+	}
+	members, err := s.combineLocally(ctx, "intersection", []*Set{other})
+	if err != nil {
+		return 0, err
+	}
+	return capCount(len(members), limit), nil
+}
+
+// intersectCard is IntersectCard for Sets that share a client, with SINTERCARD
+// or, on older servers, SINTER. A CROSSSLOT error is returned unwrapped so the
+// caller can fall back.
+func (s *Set) intersectCard(ctx context.Context, otherKey string, limit int) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if !s.noInterCard.Load() {
+		n, err := s.interCard(ctx, otherKey, limit)
+		if !isUnknownCommand(err) {
+			return n, err
+		}
+		s.noInterCard.Store(true)
+	}
+	members, err := s.combine(ctx, "intersection", s.redisClient.SInter, s.key, otherKey)
+	if err != nil {
+		return 0, err
+	}
+	return capCount(len(members), limit), nil
+}
+
+// interCard issues SINTERCARD. Unknown-command and CROSSSLOT errors are
+// returned unwrapped so the caller can fall back. The caller must hold the
+// lock.
+func (s *Set) interCard(ctx context.Context, otherKey string, limit int) (int, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	n, err := s.do(ctx, "SINTERCARD", 2, s.key, otherKey, "LIMIT", limit).Int64()
+	if isUnknownCommand(err) || s.crossSlot(err) {
+		return 0, err
+	}
+	if err != nil {
+		s.logger.Printf("Error counting intersection of %s: %v", s.key, err)
+		return 0, s.fail(fmt.Errorf("counting intersection of %s: %w", s.key, classify(err)))
+	}
+	return int(n), nil
+}
+
+func capCount(n, limit int) int {
+	if limit > 0 && n > limit {
+		return limit
+	}
+	return n
+}
+
+// MoveTo atomically moves element from the receiver Set to dst with SMOVE and
+// reports whether it was moved; false with a nil error means the receiver did
+// not contain it. The element is normalized once, by the receiver, and that
+// form is used on both keys. Since the move cannot be atomic across Redis
+// clients, MoveTo fails with ErrDifferentClients if dst uses another client.
+func (s *Set) MoveTo(dst *Set, element string) (bool, error) {
+	return s.MoveToCtx(context.Background(), dst, element)
+}
+
+// MoveToCtx is like MoveTo but uses ctx for the Redis command.
+func (s *Set) MoveToCtx(ctx context.Context, dst *Set, element string) (bool, error) {
+	if err := checkSets([]*Set{dst}); err != nil {
+		return false, s.fail(fmt.Errorf("moving %s from %s: %w", element, s.key, err))
+	}
+	dstKey := dst.lockedKey()
+	if !s.sharesClient(dst) {
+		return false, s.fail(fmt.Errorf("moving %s from %s to %s: %w", element, s.key, dstKey, ErrDifferentClients))
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if err := s.checkSlots(s.key, dstKey); err != nil {
+		return false, s.fail(fmt.Errorf("moving %s from %s to %s: %w", element, s.key, dstKey, err))
+	}
+	member, ok := s.normalize(element)
+	if !ok {
+		return false, nil
+	}
+	defer s.cache.forget(member)
+	defer dst.cache.forget(member)
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	var cmd *redis.BoolCmd
+	if s.idleTTL <= 0 && dst.idleTTL <= 0 {
+		cmd = s.redisClient.SMove(ctx, s.key, dstKey, member)
+	} else {
+		// Failures are reported through the commands themselves.
+		_, _ = s.redisClient.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+			cmd = pipe.SMove(ctx, s.key, dstKey, member)
+			s.touch(ctx, pipe)
+			dst.touchKey(ctx, pipe, dstKey)
+			return nil
+		})
+	}
+	moved, err := cmd.Result()
+	if err != nil {
+		s.logger.Printf("Error moving %s from %s to %s: %v", member, s.key, dstKey, err)
+		return false, s.fail(fmt.Errorf("moving %s from %s to %s: %w", member, s.key, dstKey, classify(err)))
+	}
+	return moved, nil
+}
+
+// UnionAll adds the members of every one of the others into the receiver Set.
+// With no others it does nothing, and nil others are rejected with ErrNilSet.
+// When all Sets use the receiver's Redis client this is a single atomic
+// SUNIONSTORE over every key; otherwise it is a Union with each other in turn.
+func (s *Set) UnionAll(others ...*Set) error {
+	return s.UnionAllCtx(context.Background(), others...)
+}
+
+// UnionAllCtx is like UnionAll but uses ctx for every Redis command.
+func (s *Set) UnionAllCtx(ctx context.Context, others ...*Set) error {
+	return s.storeAll(ctx, "union", redis.Cmdable.SUnionStore, s.UnionCtx, others)
+}
+
+// IntersectAll removes from the receiver Set every member missing from any of
+// the others; if any of them is empty the receiver becomes empty. With no
+// others it does nothing, and nil others are rejected with ErrNilSet. When all
+// Sets use the receiver's Redis client this is a single atomic SINTERSTORE
+// over every key; otherwise it is an Intersect with each other in turn.
+// IntersectSlice computes the same result without modifying the receiver.
+func (s *Set) IntersectAll(others ...*Set) error {
+	return s.IntersectAllCtx(context.Background(), others...)
+}
+
+// IntersectAllCtx is like IntersectAll but uses ctx for every Redis command.
+func (s *Set) IntersectAllCtx(ctx context.Context, others ...*Set) error {
+	return s.storeAll(ctx, "intersection", redis.Cmdable.SInterStore, s.IntersectCtx, others)
+}
+
+// SubtractAll removes from the receiver Set every member found in any of the
+// others. With no others it does nothing, and nil others are rejected with
+// ErrNilSet. When all Sets use the receiver's Redis client this is a single
+// atomic SDIFFSTORE over every key; otherwise it is a Subtract of each other
+// in turn.
+func (s *Set) SubtractAll(others ...*Set) error {
+	return s.SubtractAllCtx(context.Background(), others...)
+}
+
+// SubtractAllCtx is like SubtractAll but uses ctx for every Redis command.
+func (s *Set) SubtractAllCtx(ctx context.Context, others ...*Set) error {
+	return s.storeAll(ctx, "difference", redis.Cmdable.SDiffStore, s.SubtractCtx, others)
+}
+
+// storeAll folds others into the receiver with a single STORE command when
+// they share its client, or with pairwise otherwise.
+func (s *Set) storeAll(ctx context.Context, op string, cmd storeCmd, pairwise func(context.Context, *Set) error, others []*Set) error {
+	if err := checkSets(others); err != nil {
+		return s.fail(fmt.Errorf("computing %s into %s: %w", op, s.key, err))
+	}
+	if len(others) == 0 {
+		return nil
+	}
+	if s.serverSide(others...) {
+		if err := s.storeOthers(ctx, op, cmd, others); !isCrossSlot(err) {
+			return err
+		}
+	}
+	for _, other := range others {
+		if err := pairwise(ctx, other); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// StoreUnion writes the members found in the receiver or any of the others to
+// destKey, replacing its previous contents atomically, and returns a Set bound
+// to destKey with the receiver's client and configuration. The source Sets are
+// not modified. When all Sets use the receiver's Redis client this is a single
+// SUNIONSTORE; otherwise the union is computed client-side and written with a
+// MULTI/EXEC of DEL and SADD.
+func (s *Set) StoreUnion(destKey string, others ...*Set) (*Set, error) {
+	return s.StoreUnionCtx(context.Background(), destKey, others...)
+}
+
+// StoreUnionCtx is like StoreUnion but uses ctx for every Redis command.
+func (s *Set) StoreUnionCtx(ctx context.Context, destKey string, others ...*Set) (*Set, error) {
+	return s.storeInto(ctx, destKey, "union", redis.Cmdable.SUnionStore, others)
+}
+
+// StoreIntersect is like StoreUnion but writes the members found in the
+// receiver and in every one of the others, using SINTERSTORE.
+func (s *Set) StoreIntersect(destKey string, others ...*Set) (*Set, error) {
+	return s.StoreIntersectCtx(context.Background(), destKey, others...)
+}
+
+// StoreIntersectCtx is like StoreIntersect but uses ctx for every Redis command.
+func (s *Set) StoreIntersectCtx(ctx context.Context, destKey string, others ...*Set) (*Set, error) {
+	return s.storeInto(ctx, destKey, "intersection", redis.Cmdable.SInterStore, others)
+}
+
+// StoreDiff is like StoreUnion but writes the members of the receiver found in
+// none of the others, using SDIFFSTORE.
+func (s *Set) StoreDiff(destKey string, others ...*Set) (*Set, error) {
+	return s.StoreDiffCtx(context.Background(), destKey, others...)
+}
+
+// StoreDiffCtx is like StoreDiff but uses ctx for every Redis command.
+func (s *Set) StoreDiffCtx(ctx context.Context, destKey string, others ...*Set) (*Set, error) {
+	return s.storeInto(ctx, destKey, "difference", redis.Cmdable.SDiffStore, others)
+}
+
+func (s *Set) storeInto(ctx context.Context, destKey, op string, cmd storeCmd, others []*Set) (*Set, error) {
+	if err := checkSets(others); err != nil {
+		return nil, s.fail(fmt.Errorf("computing %s into %s: %w", op, destKey, err))
+	}
+	dest := s.derive(s.tagged(destKey))
+
+	keys := append([]string{dest.key, s.lockedKey()}, keysOf(others)...)
+	if s.sharesClient(others...) && s.sameSlot(keys...) {
+		err := dest.store(ctx, op, cmd, keys[1:]...)
+		if err == nil {
+			return dest, nil
+		}
+		if !s.crossSlot(err) {
+			return nil, s.fail(err)
+		}
+	}
+
+	members, err := s.combineLocally(ctx, op, others)
+	if err != nil {
+		return nil, err
+	}
+	if err := dest.replace(ctx, members); err != nil {
+		return nil, s.fail(err)
+	}
+	return dest, nil
+}
+
+// replace atomically overwrites the Set's key with members, which must already
+// be normalized.
+func (s *Set) replace(ctx context.Context, members []string) error {
+	defer s.cache.flush()
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	_, err := s.redisClient.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, s.key)
+		if len(members) > 0 {
+			pipe.SAdd(ctx, s.key, toArgs(members)...)
+			s.touch(ctx, pipe)
+		}
+		return nil
+	})
+	if err != nil {
+		s.logger.Printf("Error replacing members of %s: %v", s.key, err)
+		return s.fail(fmt.Errorf("replacing members of %s: %w", s.key, classify(err)))
+	}
+	return nil
+}
+
+// combineLocally evaluates op, one of "union", "intersection" or
+// "difference", over the members of s and others client-side, for Sets that
+// do not share a Redis client. The others are read before s, so that no two
+// Sets are locked at once.
+func (s *Set) combineLocally(ctx context.Context, op string, others []*Set) ([]string, error) {
+	counts := make(map[string]int)
+	for _, other := range others {
+		theirs, err := other.SliceCtx(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range theirs {
+			counts[item]++
+		}
+	}
+	ours, err := s.SliceCtx(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := []string{}
+	switch op {
+	case "union":
+		for _, item := range ours {
+			result = append(result, item)
+			delete(counts, item)
+		}
+		for item := range counts {
+			result = append(result, item)
+		}
+	case "intersection":
+		for _, item := range ours {
+			if counts[item] == len(others) {
+				result = append(result, item)
+			}
+		}
+	case "difference":
+		for _, item := range ours {
+			if counts[item] == 0 {
+				result = append(result, item)
+			}
+		}
+	}
+	return result, nil
+}
+
+// combineOthers runs combine over the receiver's key and those of others.
+func (s *Set) combineOthers(ctx context.Context, op string, cmd func(ctx context.Context, keys ...string) *redis.StringSliceCmd, others []*Set) ([]string, error) {
+	keys := keysOf(others)
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.combine(ctx, op, cmd, append([]string{s.key}, keys...)...)
+}
+
+// combine runs a read-only set-algebra command over keys. A CROSSSLOT error is
+// returned unwrapped so the caller can fall back. The caller must hold the
+// lock.
+func (s *Set) combine(ctx context.Context, op string, cmd func(ctx context.Context, keys ...string) *redis.StringSliceCmd, keys ...string) ([]string, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	result, err := cmd(ctx, keys...).Result()
+	if s.crossSlot(err) {
+		return nil, err
+	}
+	if err != nil {
+		s.logger.Printf("Error computing %s of %s: %v", op, s.key, err)
+		return nil, s.fail(fmt.Errorf("computing %s of %s: %w", op, s.key, classify(err)))
+	}
+	if result == nil {
+		result = []string{}
+	}
+	return result, nil
+}
+
+// checkSets rejects nil entries in others.
+func checkSets(others []*Set) error {
+	for _, other := range others {
+		if other == nil {
+			return ErrNilSet
+		}
+	}
+	return nil
+}
+
+// keysOf returns the current keys of sets. The caller must not hold any of
+// their locks.
+func keysOf(sets []*Set) []string {
+	keys := make([]string, len(sets))
+	for i, set := range sets {
+		keys[i] = set.lockedKey()
+	}
+	return keys
+}
+
+func toArgs(members []string) []interface{} {
+	args := make([]interface{}, len(members))
+	for i, member := range members {
+		args[i] = member
+	}
+	return args
+}
@@ -0,0 +1,628 @@
+package redisstringset
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// errCrossSlot is the reply a Redis Cluster node gives to a multi-key command
+// whose keys are in different hash slots.
+var errCrossSlot = replyError("CROSSSLOT Keys in request don't hash to the same slot")
+
+// TestCrossSlotFallback checks that each multi-key operation falls back to
+// combining the Sets client-side when the server replies CROSSSLOT, with the
+// same result as the server-side command, and that later calls check slots
+// up front instead of sending the command again.
+func TestCrossSlotFallback(t *testing.T) {
+	sorted := func(members []string, err error) string {
+		slices.Sort(members)
+		return fmt.Sprint(members, err)
+	}
+	after := func(s *Set, err error) string {
+		if err != nil {
+			return err.Error()
+		}
+		return sorted(s.Slice())
+	}
+
+	tests := []struct {
+		name string
+		cmd  string
+		run  func(left, right *Set) string
+	}{
+		{"UnionSlice", "sunion", func(l, r *Set) string { return sorted(l.UnionSlice(r)) }},
+		{"IntersectSlice", "sinter", func(l, r *Set) string { return sorted(l.IntersectSlice(r)) }},
+		{"DiffSlice", "sdiff", func(l, r *Set) string { return sorted(l.DiffSlice(r)) }},
+		{"SymmetricDifference", "sdiff", func(l, r *Set) string { return sorted(l.SymmetricDifference(r)) }},
+		{"IntersectCard", "sintercard", func(l, r *Set) string {
+			n, err := l.IntersectCard(r, 0)
+			return fmt.Sprint(n, err)
+		}},
+		{"Union", "sunionstore", func(l, r *Set) string { return after(l, l.Union(r)) }},
+		{"Intersect", "sinterstore", func(l, r *Set) string { return after(l, l.Intersect(r)) }},
+		{"Subtract", "sdiffstore", func(l, r *Set) string { return after(l, l.Subtract(r)) }},
+		{"UnionAll", "sunionstore", func(l, r *Set) string { return after(l, l.UnionAll(r)) }},
+		{"IntersectAll", "sinterstore", func(l, r *Set) string { return after(l, l.IntersectAll(r)) }},
+		{"SubtractAll", "sdiffstore", func(l, r *Set) string { return after(l, l.SubtractAll(r)) }},
+		{"StoreUnion", "sunionstore", func(l, r *Set) string { return after(l.StoreUnion("dest", r)) }},
+		{"StoreIntersect", "sinterstore", func(l, r *Set) string { return after(l.StoreIntersect("dest", r)) }},
+		{"StoreDiff", "sdiffstore", func(l, r *Set) string { return after(l.StoreDiff("dest", r)) }},
+	}
+	if keySlot("left") == keySlot("right") {
+		t.Fatal("test keys share a slot")
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sets := func(t *testing.T) (*Set, *Set, *faults) {
+				client, f, _ := newFaultyClient(t)
+				left := newClientSet(t, client, "left")
+				right := newClientSet(t, client, "right")
+				left.InsertMany("a", "b", "shared")
+				right.InsertMany("c", "shared")
+				return left, right, f
+			}
+			left, right, _ := sets(t)
+			want := tt.run(left, right)
+
+			left, right, f := sets(t)
+			f.inject(tt.cmd, -1, errCrossSlot)
+			if got := tt.run(left, right); got != want {
+				t.Errorf("after CROSSSLOT = %s, want %s", got, want)
+			}
+			if !left.clustered.Load() {
+				t.Error("CROSSSLOT was not recorded")
+			}
+			if err := left.Err(); err != nil {
+				t.Errorf("Err = %v after a fallback", err)
+			}
+			sent := f.count(tt.cmd)
+			tt.run(left, right)
+			if n := f.count(tt.cmd); n != sent {
+				t.Errorf("second call sent %s %d more times, want 0", tt.cmd, n-sent)
+			}
+		})
+	}
+}
+
+func TestUnionSlice(t *testing.T) {
+	client, server := newTestClient(t)
+	separate, _ := newTestClient(t)
+	left := newClientSet(t, client, "left")
+	left.InsertMany("a", "shared")
+	for name, right := range map[string]*Set{
+		"same client":       newClientSet(t, client, "right"),
+		"different clients": newClientSet(t, separate, "right"),
+	} {
+		t.Run(name, func(t *testing.T) {
+			right.InsertMany("b", "shared")
+			got, err := left.UnionSlice(right)
+			slices.Sort(got)
+			if want := []string{"a", "b", "shared"}; !slices.Equal(got, want) || err != nil {
+				t.Errorf("UnionSlice = %v, %v, want %v", got, err, want)
+			}
+			if got, want := mustMembers(t, server, "left"), []string{"a", "shared"}; !slices.Equal(got, want) {
+				t.Errorf("receiver = %v, want it untouched", got)
+			}
+			if got, _ := right.Slice(); len(got) != 2 {
+				t.Errorf("other = %v, want it untouched", got)
+			}
+		})
+	}
+	if _, err := left.UnionSlice(nil); !errors.Is(err, ErrNilSet) {
+		t.Errorf("UnionSlice(nil) = %v, want ErrNilSet", err)
+	}
+}
+
+// pairTests are the pairs of Sets compared by the tests of two-Set reads.
+var pairTests = []struct {
+	name        string
+	left, right []string
+}{
+	{"identical", []string{"a", "b"}, []string{"a", "b"}},
+	{"disjoint", []string{"a", "b"}, []string{"c", "d"}},
+	{"subset", []string{"a"}, []string{"a", "b"}},
+	{"superset", []string{"a", "b"}, []string{"a"}},
+	{"partial overlap", []string{"a", "b"}, []string{"b", "c"}},
+	{"empty receiver", nil, []string{"a"}},
+	{"empty other", []string{"a"}, nil},
+}
+
+// runPairs calls check for each of pairTests on Sets sharing a client and on
+// Sets on different clients, so both the server-side and the client-side path
+// are covered.
+func runPairs(t *testing.T, check func(t *testing.T, left, right *Set, pair string)) {
+	for _, tt := range pairTests {
+		for _, shared := range []bool{true, false} {
+			name := tt.name + "/different clients"
+			if shared {
+				name = tt.name + "/same client"
+			}
+			t.Run(name, func(t *testing.T) {
+				client, _ := newTestClient(t)
+				other := client
+				if !shared {
+					other, _ = newTestClient(t)
+				}
+				left, right := newClientSet(t, client, "left"), newClientSet(t, other, "right")
+				left.InsertMany(tt.left...)
+				right.InsertMany(tt.right...)
+				check(t, left, right, tt.name)
+			})
+		}
+	}
+}
+
+func TestDiffSlice(t *testing.T) {
+	want := map[string][]string{
+		"identical":       {},
+		"disjoint":        {"a", "b"},
+		"subset":          {},
+		"superset":        {"b"},
+		"partial overlap": {"a"},
+		"empty receiver":  {},
+		"empty other":     {"a"},
+	}
+	runPairs(t, func(t *testing.T, left, right *Set, pair string) {
+		got, err := left.DiffSlice(right)
+		slices.Sort(got)
+		if !slices.Equal(got, want[pair]) || got == nil || err != nil {
+			t.Errorf("DiffSlice = %#v, %v, want %v", got, err, want[pair])
+		}
+	})
+
+	s, _ := newTestSet(t, "left")
+	if _, err := s.DiffSlice(nil); !errors.Is(err, ErrNilSet) {
+		t.Errorf("DiffSlice(nil) = %v, want ErrNilSet", err)
+	}
+}
+
+func TestSymmetricDifference(t *testing.T) {
+	want := map[string][]string{
+		"identical":       {},
+		"disjoint":        {"a", "b", "c", "d"},
+		"subset":          {"b"},
+		"superset":        {"b"},
+		"partial overlap": {"a", "c"},
+		"empty receiver":  {"a"},
+		"empty other":     {"a"},
+	}
+	runPairs(t, func(t *testing.T, left, right *Set, pair string) {
+		got, err := left.SymmetricDifference(right)
+		slices.Sort(got)
+		if !slices.Equal(got, want[pair]) || got == nil || err != nil {
+			t.Errorf("SymmetricDifference = %#v, %v, want %v", got, err, want[pair])
+		}
+	})
+
+	s, _ := newTestSet(t, "left")
+	if _, err := s.SymmetricDifference(nil); !errors.Is(err, ErrNilSet) {
+		t.Errorf("SymmetricDifference(nil) = %v, want ErrNilSet", err)
+	}
+}
+
+// errUnknownCommand is the reply of a server too old for the command sent.
+func errUnknownCommand(cmd string) error {
+	return replyError("ERR unknown command '" + cmd + "', with args beginning with: ")
+}
+
+func TestIntersectCard(t *testing.T) {
+	tests := []struct {
+		name  string
+		limit int
+		old   bool
+		want  int
+	}{
+		{"no limit", 0, false, 5},
+		{"limit reached", 2, false, 2},
+		{"limit above count", 9, false, 5},
+		{"fallback without limit", 0, true, 5},
+		{"fallback with limit", 2, true, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, f, _ := newFaultyClient(t)
+			left := newClientSet(t, client, "left")
+			right := newClientSet(t, client, "right")
+			left.InsertMany("a", "b", "c", "d", "e", "only-left")
+			right.InsertMany("a", "b", "c", "d", "e", "only-right")
+			if tt.old {
+				f.inject("sintercard", -1, errUnknownCommand("SINTERCARD"))
+			}
+			for i := 0; i < 2; i++ {
+				if n, err := left.IntersectCard(right, tt.limit); n != tt.want || err != nil {
+					t.Errorf("IntersectCard = %d, %v, want %d", n, err, tt.want)
+				}
+			}
+			// An old server is asked for SINTERCARD only once.
+			wantInterCard, wantInter := 2, 0
+			if tt.old {
+				wantInterCard, wantInter = 1, 2
+			}
+			if n := f.count("sintercard"); n != wantInterCard {
+				t.Errorf("sent sintercard %d times, want %d", n, wantInterCard)
+			}
+			if n := f.count("sinter"); n != wantInter {
+				t.Errorf("sent sinter %d times, want %d", n, wantInter)
+			}
+			if err := left.Err(); err != nil {
+				t.Errorf("Err = %v", err)
+			}
+		})
+	}
+
+	s, _ := newTestSet(t, "left")
+	if _, err := s.IntersectCard(nil, 0); !errors.Is(err, ErrNilSet) {
+		t.Errorf("IntersectCard(nil) = %v, want ErrNilSet", err)
+	}
+}
+
+func TestMutatingAlgebra(t *testing.T) {
+	tests := []struct {
+		name string
+		run  func(s, other *Set) error
+		want map[string][]string
+		self []string
+	}{
+		{"Union", (*Set).Union, map[string][]string{
+			"identical":       {"a", "b"},
+			"disjoint":        {"a", "b", "c", "d"},
+			"subset":          {"a", "b"},
+			"superset":        {"a", "b"},
+			"partial overlap": {"a", "b", "c"},
+			"empty receiver":  {"a"},
+			"empty other":     {"a"},
+		}, []string{"a", "b"}},
+		{"Subtract", (*Set).Subtract, map[string][]string{
+			"identical":       {},
+			"disjoint":        {"a", "b"},
+			"subset":          {},
+			"superset":        {"b"},
+			"partial overlap": {"a"},
+			"empty receiver":  {},
+			"empty other":     {"a"},
+		}, []string{}},
+		{"Intersect", (*Set).Intersect, map[string][]string{
+			"identical":       {"a", "b"},
+			"disjoint":        {},
+			"subset":          {"a"},
+			"superset":        {"a"},
+			"partial overlap": {"b"},
+			"empty receiver":  {},
+			"empty other":     {},
+		}, []string{"a", "b"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			check := func(t *testing.T, left, right *Set, want []string) {
+				t.Helper()
+				errc := make(chan error, 1)
+				go func() { errc <- tt.run(left, right) }()
+				select {
+				case err := <-errc:
+					if err != nil {
+						t.Fatal(err)
+					}
+				case <-time.After(5 * time.Second):
+					t.Fatalf("%s did not return", tt.name)
+				}
+				got, _ := left.Slice()
+				slices.Sort(got)
+				if !slices.Equal(got, want) {
+					t.Errorf("receiver = %v, want %v", got, want)
+				}
+			}
+			runPairs(t, func(t *testing.T, left, right *Set, pair string) {
+				check(t, left, right, tt.want[pair])
+			})
+
+			s, _ := newTestSet(t, "left")
+			s.InsertMany("a", "b")
+			check(t, s, s, tt.self)
+			if err := tt.run(s, nil); !errors.Is(err, ErrNilSet) {
+				t.Errorf("%s(nil) = %v, want ErrNilSet", tt.name, err)
+			}
+		})
+	}
+}
+
+// roundTrips is a go-redis hook counting the commands and pipelines sent to
+// the server, each pipeline counting once.
+type roundTrips struct {
+	n atomic.Int64
+}
+
+func (r *roundTrips) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+func (r *roundTrips) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		r.n.Add(1)
+		return next(ctx, cmd)
+	}
+}
+
+func (r *roundTrips) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		r.n.Add(1)
+		return next(ctx, cmds)
+	}
+}
+
+// benchmarkAlgebra measures run on a receiver and an other Set of size
+// members each, half of them shared, once on a shared client, where run uses
+// the server-side command, and once on different clients, which forces the
+// client-side path. The receiver is restored before every call, and the round
+// trips of each call are reported as trips/op.
+func benchmarkAlgebra(b *testing.B, size int, run func(s, other *Set) error) {
+	left, right := make([]string, size), make([]string, size)
+	for i := range left {
+		left[i] = fmt.Sprintf("member-%d", i)
+		right[i] = fmt.Sprintf("member-%d", i+size/2)
+	}
+	for _, shared := range []bool{true, false} {
+		name := "client-side"
+		if shared {
+			name = "server-side"
+		}
+		b.Run(name, func(b *testing.B) {
+			client, _ := newTestClient(b)
+			elsewhere := client
+			if !shared {
+				elsewhere, _ = newTestClient(b)
+			}
+			s, other := newClientSet(b, client, "left"), newClientSet(b, elsewhere, "right")
+			if err := s.InsertMany(left...); err != nil {
+				b.Fatal(err)
+			}
+			if err := other.InsertMany(right...); err != nil {
+				b.Fatal(err)
+			}
+			ctx := context.Background()
+			if err := client.Copy(ctx, "left", "pristine", 0, false).Err(); err != nil {
+				b.Fatal(err)
+			}
+			trips := new(roundTrips)
+			client.AddHook(trips)
+			if !shared {
+				elsewhere.AddHook(trips)
+			}
+			var sent int64
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				if err := client.Copy(ctx, "pristine", "left", 0, true).Err(); err != nil {
+					b.Fatal(err)
+				}
+				before := trips.n.Load()
+				b.StartTimer()
+				if err := run(s, other); err != nil {
+					b.Fatal(err)
+				}
+				sent += trips.n.Load() - before
+			}
+			b.ReportMetric(float64(sent)/float64(b.N), "trips/op")
+		})
+	}
+}
+
+// BenchmarkUnion compares SUNIONSTORE with the client-side Union on 100000
+// member Sets.
+func BenchmarkUnion(b *testing.B) {
+	benchmarkAlgebra(b, 100000, (*Set).Union)
+}
+
+// BenchmarkIntersect compares SINTERSTORE with the client-side Intersect on
+// 100000 member Sets.
+func BenchmarkIntersect(b *testing.B) {
+	benchmarkAlgebra(b, 100000, (*Set).Intersect)
+}
+
+// BenchmarkSubtract compares SDIFFSTORE with the client-side Subtract on
+// 100000 member Sets.
+func BenchmarkSubtract(b *testing.B) {
+	benchmarkAlgebra(b, 100000, (*Set).Subtract)
+}
+
+// newShards returns n Sets on client holding members of the form
+// "<prefix>-<shard>-<i>", size of them each, plus "shared" in every one.
+func newShards(t testing.TB, client redis.Cmdable, prefix string, n, size int) []*Set {
+	t.Helper()
+	shards := make([]*Set, n)
+	for i := range shards {
+		shards[i] = newClientSet(t, client, fmt.Sprintf("%s:%d", prefix, i))
+		members := []string{"shared"}
+		for j := 0; j < size; j++ {
+			members = append(members, fmt.Sprintf("%s-%d-%d", prefix, i, j))
+		}
+		if err := shards[i].InsertMany(members...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return shards
+}
+
+func TestUnionAll(t *testing.T) {
+	want := []string{"a", "shard-0-0", "shard-0-1", "shard-1-0", "shard-1-1", "shard-2-0", "shard-2-1", "shared"}
+	for _, shared := range []bool{true, false} {
+		name := "different clients"
+		if shared {
+			name = "same client"
+		}
+		t.Run(name, func(t *testing.T) {
+			client, f, server := newFaultyClient(t)
+			shards := newShards(t, client, "shard", 3, 2)
+			if !shared {
+				elsewhere, _ := newTestClient(t)
+				shards = append(shards[:2], newShards(t, elsewhere, "shard", 3, 2)[2])
+			}
+			s := newClientSet(t, client, "merged")
+			s.Insert("a")
+			if err := s.UnionAll(shards...); err != nil {
+				t.Fatal(err)
+			}
+			if got := mustMembers(t, server, "merged"); !slices.Equal(got, want) {
+				t.Errorf("members = %v, want %v", got, want)
+			}
+			if n := f.count("sunionstore"); shared && n != 1 {
+				t.Errorf("sent %d SUNIONSTOREs, want 1", n)
+			}
+			if got := mustMembers(t, server, "shard:0"); len(got) != 3 {
+				t.Errorf("shard:0 = %v, want it untouched", got)
+			}
+		})
+	}
+
+	s, server := newTestSet(t, "merged")
+	s.Insert("a")
+	if err := s.UnionAll(); err != nil {
+		t.Errorf("UnionAll() = %v", err)
+	}
+	other, _ := newTestSet(t, "other")
+	other.Insert("b")
+	if err := s.UnionAll(other, nil); !errors.Is(err, ErrNilSet) {
+		t.Errorf("UnionAll(other, nil) = %v, want ErrNilSet", err)
+	}
+	if got := mustMembers(t, server, "merged"); !slices.Equal(got, []string{"a"}) {
+		t.Errorf("members = %v, want [a] untouched", got)
+	}
+}
+
+// BenchmarkUnionAll compares merging 16 Sets of 1000 members with one
+// UnionAll against 16 pairwise Unions.
+func BenchmarkUnionAll(b *testing.B) {
+	for _, bb := range []struct {
+		name string
+		run  func(s *Set, shards []*Set) error
+	}{
+		{"UnionAll", func(s *Set, shards []*Set) error { return s.UnionAll(shards...) }},
+		{"pairwise", func(s *Set, shards []*Set) error {
+			for _, shard := range shards {
+				if err := s.Union(shard); err != nil {
+					return err
+				}
+			}
+			return nil
+		}},
+	} {
+		b.Run(bb.name, func(b *testing.B) {
+			client, _ := newTestClient(b)
+			shards := newShards(b, client, "shard", 16, 1000)
+			s := newClientSet(b, client, "merged")
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				if err := s.Clear(); err != nil {
+					b.Fatal(err)
+				}
+				b.StartTimer()
+				if err := bb.run(s, shards); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestSubtractAll(t *testing.T) {
+	for _, shared := range []bool{true, false} {
+		name := "different clients"
+		if shared {
+			name = "same client"
+		}
+		t.Run(name, func(t *testing.T) {
+			client, f, server := newFaultyClient(t)
+			elsewhere := client
+			if !shared {
+				elsewhere, _ = newTestClient(t)
+			}
+			s := newClientSet(t, client, "working")
+			s.InsertMany("a", "b", "c", "d", "e")
+			// The denylists overlap on c and each other member once.
+			denylists := []*Set{
+				newClientSet(t, client, "deny:0"),
+				newClientSet(t, elsewhere, "deny:1"),
+				newClientSet(t, client, "deny:2"),
+			}
+			denylists[0].InsertMany("a", "c")
+			denylists[1].InsertMany("c", "d", "x")
+			denylists[2].InsertMany("c")
+			if err := s.SubtractAll(denylists...); err != nil {
+				t.Fatal(err)
+			}
+			if got := mustMembers(t, server, "working"); !slices.Equal(got, []string{"b", "e"}) {
+				t.Errorf("members = %v, want [b e]", got)
+			}
+			if n := f.count("sdiffstore"); shared && n != 1 {
+				t.Errorf("sent %d SDIFFSTOREs, want 1", n)
+			}
+			if got := mustMembers(t, server, "deny:0"); !slices.Equal(got, []string{"a", "c"}) {
+				t.Errorf("deny:0 = %v, want it untouched", got)
+			}
+		})
+	}
+
+	s, server := newTestSet(t, "working")
+	s.InsertMany("a", "b")
+	if err := s.SubtractAll(); err != nil {
+		t.Errorf("SubtractAll() = %v", err)
+	}
+	other, _ := newTestSet(t, "deny")
+	other.Insert("a")
+	if err := s.SubtractAll(nil, other); !errors.Is(err, ErrNilSet) {
+		t.Errorf("SubtractAll(nil, other) = %v, want ErrNilSet", err)
+	}
+	if got := mustMembers(t, server, "working"); !slices.Equal(got, []string{"a", "b"}) {
+		t.Errorf("members = %v, want [a b] untouched", got)
+	}
+}
+
+// BenchmarkSubtractAll compares removing 16 denylists of 1000 members from a
+// Set with one SubtractAll against 16 sequential Subtracts.
+func BenchmarkSubtractAll(b *testing.B) {
+	for _, bb := range []struct {
+		name string
+		run  func(s *Set, denylists []*Set) error
+	}{
+		{"SubtractAll", func(s *Set, denylists []*Set) error { return s.SubtractAll(denylists...) }},
+		{"sequential", func(s *Set, denylists []*Set) error {
+			for _, denylist := range denylists {
+				if err := s.Subtract(denylist); err != nil {
+					return err
+				}
+			}
+			return nil
+		}},
+	} {
+		b.Run(bb.name, func(b *testing.B) {
+			client, _ := newTestClient(b)
+			denylists := newShards(b, client, "deny", 16, 1000)
+			s := newClientSet(b, client, "working")
+			ctx := context.Background()
+			if err := s.UnionAll(denylists...); err != nil {
+				b.Fatal(err)
+			}
+			if err := client.Copy(ctx, "working", "pristine", 0, false).Err(); err != nil {
+				b.Fatal(err)
+			}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				if err := client.Copy(ctx, "pristine", "working", 0, true).Err(); err != nil {
+					b.Fatal(err)
+				}
+				b.StartTimer()
+				if err := bb.run(s, denylists); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
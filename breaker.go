@@ -0,0 +1,110 @@
+package redisstringset
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// WithCircuitBreaker gives the Set its own CircuitBreaker, see
+// NewCircuitBreaker, so that after threshold consecutive transient failures
+// its commands fail fast with ErrCircuitOpen instead of waiting on an
+// unreachable server.
+func WithCircuitBreaker(threshold int, cooldown time.Duration) Option {
+	return func(s *Set) {
+		s.breaker = NewCircuitBreaker(threshold, cooldown)
+	}
+}
+
+// WithSharedCircuitBreaker makes the Set use b, which may also guard other
+// Sets, typically all those using the same Redis server, so that failures seen
+// by any of them open it for all. A nil b disables the breaker.
+func WithSharedCircuitBreaker(b *CircuitBreaker) Option {
+	return func(s *Set) {
+		s.breaker = b
+	}
+}
+
+// CircuitBreaker stops commands from reaching Redis while it appears to be
+// down. It guards the commands WithRetry retries, each retry counting as an
+// attempt, and SPOP, which is never retried. After threshold consecutive
+// attempts fail with ErrUnavailable or ErrTimeout the breaker opens and
+// attempts fail with ErrCircuitOpen without a round trip. Once cooldown has
+// passed a single attempt is let through as a probe: if it reaches the server,
+// whatever the reply, the breaker closes, otherwise it opens for another
+// cooldown. A nil *CircuitBreaker never opens. A CircuitBreaker is safe for
+// concurrent use; Sets derived from one, for example by Union, share it.
+type CircuitBreaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	failures  int
+	open      bool
+	openedAt  time.Time
+	probing   bool
+}
+
+// NewCircuitBreaker returns a closed CircuitBreaker opening after threshold
+// consecutive failures for cooldown, or nil, a breaker that never opens, if
+// threshold is not positive.
+func NewCircuitBreaker(threshold int, cooldown time.Duration) *CircuitBreaker {
+	if threshold < 1 {
+		return nil
+	}
+	return &CircuitBreaker{threshold: threshold, cooldown: cooldown}
+}
+
+// Open reports whether the breaker is open, including while a probe is in
+// flight.
+func (b *CircuitBreaker) Open() bool {
+	if b == nil {
+		return false
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.open
+}
+
+// allow returns ErrCircuitOpen unless an attempt may be made at now. Every
+// allowed attempt must be followed by a call to done.
+func (b *CircuitBreaker) allow(now time.Time) error {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.open {
+		return nil
+	}
+	if b.probing || now.Sub(b.openedAt) < b.cooldown {
+		return ErrCircuitOpen
+	}
+	b.probing = true
+	return nil
+}
+
+// done records the outcome of an allowed attempt finished at now.
+func (b *CircuitBreaker) done(err error, now time.Time) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	probe := b.probing
+	b.probing = false
+	switch {
+	case errors.Is(err, context.Canceled):
+		// The caller gave up, which says nothing about the server; a
+		// cancelled probe leaves the breaker to the next attempt.
+	case retryable(err):
+		b.failures++
+		if probe || b.failures >= b.threshold {
+			b.open = true
+			b.openedAt = now
+		}
+	default:
+		b.failures = 0
+		b.open = false
+	}
+}
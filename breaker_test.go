@@ -0,0 +1,137 @@
+package redisstringset
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock is a clock for WithClock that only moves when told to.
+type fakeClock struct {
+	mu sync.Mutex
+	t  time.Time
+}
+
+func (c *fakeClock) now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.t
+}
+
+func (c *fakeClock) advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.t = c.t.Add(d)
+}
+
+// TestCircuitBreaker walks one breaker through its states, each step calling
+// Has with err injected into SISMEMBER, or none if err is nil.
+func TestCircuitBreaker(t *testing.T) {
+	steps := []struct {
+		name    string
+		advance time.Duration
+		err     error
+		want    error
+		sent    int
+		open    bool
+	}{
+		{"first failure", 0, errRefused, ErrUnavailable, 1, false},
+		{"second failure", 0, errRefused, ErrUnavailable, 1, false},
+		{"threshold reached", 0, errRefused, ErrUnavailable, 1, true},
+		{"fails fast while open", 0, errRefused, ErrCircuitOpen, 0, true},
+		{"fails fast during cooldown", 59 * time.Second, nil, ErrCircuitOpen, 0, true},
+		{"failed probe reopens", time.Second, errRefused, ErrUnavailable, 1, true},
+		{"cooldown restarts", 59 * time.Second, nil, ErrCircuitOpen, 0, true},
+		{"successful probe closes", time.Second, nil, nil, 1, false},
+		{"closed", 0, nil, nil, 1, false},
+		{"error replies reach the server", 0, errWrongType, ErrWrongType, 1, false},
+		{"failures counted afresh", 0, errRefused, ErrUnavailable, 1, false},
+	}
+
+	clock := &fakeClock{t: time.Unix(0, 0)}
+	client, f, _ := newFaultyClient(t)
+	s := newClientSet(t, client, "breaker", WithCircuitBreaker(3, time.Minute), WithClock(clock.now))
+	for _, step := range steps {
+		clock.advance(step.advance)
+		f.heal()
+		if step.err != nil {
+			f.inject("sismember", -1, step.err)
+		}
+		sent := f.count("sismember")
+		_, err := s.Has("a")
+		if !errors.Is(err, step.want) || (err == nil) != (step.want == nil) {
+			t.Errorf("%s: err = %v, want %v", step.name, err, step.want)
+		}
+		if n := f.count("sismember") - sent; n != step.sent {
+			t.Errorf("%s: sent sismember %d times, want %d", step.name, n, step.sent)
+		}
+		if open := s.breaker.Open(); open != step.open {
+			t.Errorf("%s: Open = %v, want %v", step.name, open, step.open)
+		}
+	}
+}
+
+// TestCircuitBreakerSingleProbe checks that a half-open breaker lets a single
+// probe through, failing the commands of every Set sharing it meanwhile.
+func TestCircuitBreakerSingleProbe(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(0, 0)}
+	breaker := NewCircuitBreaker(1, time.Minute)
+	client, f, _ := newFaultyClient(t)
+	s := newClientSet(t, client, "probe", WithSharedCircuitBreaker(breaker), WithClock(clock.now))
+	other := newClientSet(t, client, "bystander", WithSharedCircuitBreaker(breaker), WithClock(clock.now))
+
+	f.inject("sismember", 1, errRefused)
+	if _, err := s.Has("a"); !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("Has = %v, want ErrUnavailable", err)
+	}
+	if _, err := other.Has("a"); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("Has on the bystander = %v, want ErrCircuitOpen", err)
+	}
+	clock.advance(time.Minute)
+
+	// While the probe is in flight, every other command fails fast.
+	during := make(map[string]error)
+	f.injectThen("sismember", 1, errRefused, func() {
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		record := func(name string, err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			during[name] = err
+		}
+		wg.Add(3)
+		go func() {
+			defer wg.Done()
+			_, err := other.Has("a")
+			record("Has", err)
+		}()
+		go func() {
+			defer wg.Done()
+			record("Insert", other.Insert("a"))
+		}()
+		go func() {
+			defer wg.Done()
+			_, _, err := other.Pop()
+			record("Pop", err)
+		}()
+		wg.Wait()
+	})
+	if _, err := s.Has("a"); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("probe = %v, want ErrUnavailable", err)
+	}
+	for _, name := range []string{"Has", "Insert", "Pop"} {
+		if err := during[name]; !errors.Is(err, ErrCircuitOpen) {
+			t.Errorf("%s during the probe = %v, want ErrCircuitOpen", name, err)
+		}
+	}
+	if n := f.count("sismember"); n != 2 {
+		t.Errorf("sent sismember %d times, want only the failure and the probe", n)
+	}
+	if n := f.count("sadd") + f.count("spop"); n != 0 {
+		t.Errorf("sent %d commands during the probe, want 0", n)
+	}
+	if !breaker.Open() {
+		t.Error("breaker closed after a failed probe")
+	}
+}
@@ -0,0 +1,181 @@
+package redisstringset
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// WithWriteBuffer makes Insert, InsertMany and Remove queue their members
+// locally instead of sending them at once. The queue is sent with batched
+// SADD and SREM commands once it holds maxBatch members, by the inserting
+// call, or maxDelay after the first member was queued, by a background
+// flush, as well as by Flush and Close. Queuing the same member twice keeps
+// only the latest operation. If maxDelay is not positive only the size limit,
+// Flush and Close send the queue. The buffer is disabled if maxBatch is not
+// positive.
+//
+// Has answers from the queue for queued members. Every other read, including
+// Slice and Len, sees queued writes only once they are flushed; call Flush
+// first when that matters. RemoveMany, Clear and the other writes bypass the
+// queue: RemoveMany flushes it first, so that it can report accurate counts,
+// while Clear, Destroy and SliceAndClear discard it. A failed background flush
+// is logged and recorded for Err, and its members are queued again to be
+// retried by the next flush unless they were queued anew meanwhile.
+func WithWriteBuffer(maxBatch int, maxDelay time.Duration) Option {
+	return func(s *Set) {
+		s.buffer = newWriteBuffer(maxBatch, maxDelay)
+	}
+}
+
+// Flush sends every member queued by WithWriteBuffer to Redis and returns the
+// first failure. It does nothing for Sets without a write buffer.
+func (s *Set) Flush() error {
+	return s.FlushCtx(context.Background())
+}
+
+// FlushCtx is like Flush but uses ctx for every Redis command.
+func (s *Set) FlushCtx(ctx context.Context) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.flushBuffer(ctx)
+}
+
+// writeBuffer queues writes for WithWriteBuffer. A nil *writeBuffer is a
+// disabled buffer.
+type writeBuffer struct {
+	maxBatch int
+	maxDelay time.Duration
+
+	// flushMu serializes flushes so that the writes of one flush reach Redis
+	// before those of the next.
+	flushMu sync.Mutex
+
+	mu sync.Mutex
+	// pending maps each queued member to true for an insertion and false for
+	// a removal.
+	pending map[string]bool
+	timer   *time.Timer
+}
+
+func newWriteBuffer(maxBatch int, maxDelay time.Duration) *writeBuffer {
+	if maxBatch < 1 {
+		return nil
+	}
+	return &writeBuffer{
+		maxBatch: maxBatch,
+		maxDelay: maxDelay,
+		pending:  make(map[string]bool),
+	}
+}
+
+// clone returns an empty buffer with the same limits.
+func (b *writeBuffer) clone() *writeBuffer {
+	if b == nil {
+		return nil
+	}
+	return newWriteBuffer(b.maxBatch, b.maxDelay)
+}
+
+// lookup reports whether member is queued and, if so, whether for insertion.
+func (b *writeBuffer) lookup(member string) (insert, ok bool) {
+	if b == nil {
+		return false, false
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	insert, ok = b.pending[member]
+	return insert, ok
+}
+
+// discard drops every queued member.
+func (b *writeBuffer) discard() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	clear(b.pending)
+}
+
+// enqueue queues already normalized members for insertion or removal and
+// flushes the buffer if it is full. The caller must hold the lock.
+func (s *Set) enqueue(ctx context.Context, members []string, insert bool) error {
+	b := s.buffer
+	b.mu.Lock()
+	for _, member := range members {
+		b.pending[member] = insert
+		s.cache.forget(member)
+	}
+	full := len(b.pending) >= b.maxBatch
+	if !full {
+		s.scheduleFlush()
+	}
+	b.mu.Unlock()
+	if full {
+		return s.flushBuffer(ctx)
+	}
+	return nil
+}
+
+// scheduleFlush starts the background flush timer unless it is running or
+// disabled. The caller must hold s.buffer.mu.
+func (s *Set) scheduleFlush() {
+	b := s.buffer
+	if b.timer != nil || b.maxDelay <= 0 || len(b.pending) == 0 {
+		return
+	}
+	b.timer = time.AfterFunc(b.maxDelay, func() {
+		s.mu.RLock()
+		defer s.mu.RUnlock()
+		s.flushBuffer(context.Background())
+	})
+}
+
+// flushBuffer sends the queued members, queuing them again on failure. The
+// caller must hold the lock.
+func (s *Set) flushBuffer(ctx context.Context) error {
+	b := s.buffer
+	if b == nil {
+		return nil
+	}
+	b.flushMu.Lock()
+	defer b.flushMu.Unlock()
+
+	b.mu.Lock()
+	batch := b.pending
+	b.pending = make(map[string]bool)
+	if b.timer != nil {
+		b.timer.Stop()
+		b.timer = nil
+	}
+	b.mu.Unlock()
+	if len(batch) == 0 {
+		return nil
+	}
+
+	var inserts, removals []string
+	for member, insert := range batch {
+		if insert {
+			inserts = append(inserts, member)
+		} else {
+			removals = append(removals, member)
+		}
+	}
+	_, err := s.addMembers(ctx, inserts)
+	if err == nil {
+		_, err = s.removeMembers(ctx, removals)
+	}
+	if err != nil {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		for member, insert := range batch {
+			if _, requeued := b.pending[member]; !requeued {
+				b.pending[member] = insert
+			}
+		}
+		s.scheduleFlush()
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package redisstringset
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestWriteBufferRequeue(t *testing.T) {
+	tests := []struct {
+		name string
+		seed []string
+		// queue queues writes before the failing flush.
+		queue func(s *Set)
+		fail  string
+		// during runs while the failing command is sent, if not nil.
+		during func(s *Set)
+		// queued are Has's answers from the queue after the failure.
+		queued map[string]bool
+		// want is the Set's content after a healed flush.
+		want []string
+	}{
+		{
+			name:   "failed inserts",
+			queue:  func(s *Set) { s.InsertMany("a", "b") },
+			fail:   "sadd",
+			queued: map[string]bool{"a": true, "b": true},
+			want:   []string{"a", "b"},
+		},
+		{
+			name:   "failed removal",
+			seed:   []string{"a", "b"},
+			queue:  func(s *Set) { s.Remove("a") },
+			fail:   "srem",
+			queued: map[string]bool{"a": false, "b": true},
+			want:   []string{"b"},
+		},
+		{
+			name:   "queued anew during the flush",
+			queue:  func(s *Set) { s.InsertMany("a", "b") },
+			fail:   "sadd",
+			during: func(s *Set) { s.Remove("a") },
+			queued: map[string]bool{"a": false, "b": true},
+			want:   []string{"b"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, f, server := newFaultyClient(t)
+			s := newClientSet(t, client, "buffered", WithWriteBuffer(100, 0))
+			if len(tt.seed) > 0 {
+				server.SAdd("buffered", tt.seed...)
+			}
+			tt.queue(s)
+			var during func()
+			if tt.during != nil {
+				during = func() { tt.during(s) }
+			}
+			f.injectThen(tt.fail, 1, errRefused, during)
+
+			if err := s.Flush(); !errors.Is(err, ErrUnavailable) {
+				t.Fatalf("failing Flush = %v, want ErrUnavailable", err)
+			}
+			f.heal()
+			for member, want := range tt.queued {
+				if got, err := s.Has(member); got != want || err != nil {
+					t.Errorf("Has(%s) after the failure = %v, %v, want %v from the queue", member, got, err, want)
+				}
+			}
+			if err := s.Flush(); err != nil {
+				t.Fatalf("healed Flush: %v", err)
+			}
+			if got := mustMembers(t, server, "buffered"); !slices.Equal(got, tt.want) {
+				t.Errorf("members = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteBufferBackgroundRetry(t *testing.T) {
+	client, f, server := newFaultyClient(t)
+	s := newClientSet(t, client, "background", WithWriteBuffer(100, 5*time.Millisecond))
+	f.inject("sadd", 2, errRefused)
+	if err := s.Insert("a"); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !server.Exists("background") {
+		if time.Now().After(deadline) {
+			t.Fatal("failed background flushes were not retried")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if got := f.count("sadd"); got != 3 {
+		t.Errorf("sent %d SADDs, want 3", got)
+	}
+	if err := s.Err(); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("Err = %v, want the background failure recorded", err)
+	}
+}
+
+func TestWriteBufferFlushesWhenFull(t *testing.T) {
+	s, server := newTestSet(t, "full", WithWriteBuffer(3, 0))
+	s.InsertMany("a", "b")
+	if server.Exists("full") {
+		t.Fatal("flushed before the buffer was full")
+	}
+	s.Insert("c")
+	if got := mustMembers(t, server, "full"); !slices.Equal(got, []string{"a", "b", "c"}) {
+		t.Errorf("members = %v, want the full buffer flushed", got)
+	}
+}
@@ -0,0 +1,119 @@
+package redisstringset
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// InsertBytes adds b to the Set exactly as given, skipping the Set's
+// normalization, WithTrimSpace and RejectEmpty, for opaque binary members
+// such as hashes. The bytes are passed to go-redis without being converted to
+// a string. The Bytes methods share the Set's key with the string methods,
+// which see raw members as stored; mixing raw and normalized writes of the
+// same values is the caller's responsibility. Raw writes bypass
+// WithWriteBuffer and HasBytes bypasses WithLocalCache.
+func (s *Set) InsertBytes(b []byte) error {
+	return s.InsertBytesCtx(context.Background(), b)
+}
+
+// InsertBytesCtx is like InsertBytes but uses ctx for the Redis command.
+func (s *Set) InsertBytesCtx(ctx context.Context, b []byte) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	s.forgetBytes(b)
+
+	err := s.attempt(ctx, func(ctx context.Context) error {
+		return s.write(ctx, func(c redis.Cmdable) *redis.IntCmd {
+			return c.SAdd(ctx, s.key, b)
+		}).Err()
+	})
+	if err != nil {
+		s.logger.Printf("Error inserting raw member into %s: %v", s.key, err)
+		return s.fail(fmt.Errorf("inserting raw member into %s: %w", s.key, classify(err)))
+	}
+	return nil
+}
+
+// HasBytes reports whether b is a member of the Set, unnormalized as with
+// InsertBytes.
+func (s *Set) HasBytes(b []byte) (bool, error) {
+	return s.HasBytesCtx(context.Background(), b)
+}
+
+// HasBytesCtx is like HasBytes but uses ctx for the Redis command.
+func (s *Set) HasBytesCtx(ctx context.Context, b []byte) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var result bool
+	err := s.attempt(ctx, func(ctx context.Context) (err error) {
+		result, err = s.redisClient.SIsMember(ctx, s.key, b).Result()
+		return err
+	})
+	if err != nil {
+		s.logger.Printf("Error checking raw membership in %s: %v", s.key, err)
+		return false, s.fail(fmt.Errorf("checking raw membership in %s: %w", s.key, classify(err)))
+	}
+	return result, nil
+}
+
+// RemoveBytes deletes b from the Set, unnormalized as with InsertBytes.
+func (s *Set) RemoveBytes(b []byte) error {
+	return s.RemoveBytesCtx(context.Background(), b)
+}
+
+// RemoveBytesCtx is like RemoveBytes but uses ctx for the Redis command.
+func (s *Set) RemoveBytesCtx(ctx context.Context, b []byte) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	s.forgetBytes(b)
+
+	err := s.attempt(ctx, func(ctx context.Context) error {
+		return s.write(ctx, func(c redis.Cmdable) *redis.IntCmd {
+			return c.SRem(ctx, s.key, b)
+		}).Err()
+	})
+	if err != nil {
+		s.logger.Printf("Error removing raw member from %s: %v", s.key, err)
+		return s.fail(fmt.Errorf("removing raw member from %s: %w", s.key, classify(err)))
+	}
+	return nil
+}
+
+// SliceBytes is like Slice but returns each member as a byte slice. go-redis
+// reads every reply as strings, so the members are copied once more, into a
+// single buffer shared by the returned slices; each slice's capacity is its
+// length, so appending to one never overwrites another.
+func (s *Set) SliceBytes() ([][]byte, error) {
+	return s.SliceBytesCtx(context.Background())
+}
+
+// SliceBytesCtx is like SliceBytes but uses ctx for every Redis command.
+func (s *Set) SliceBytesCtx(ctx context.Context) ([][]byte, error) {
+	members, err := s.SliceCtx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	size := 0
+	for _, member := range members {
+		size += len(member)
+	}
+	buf := make([]byte, 0, size)
+	result := make([][]byte, len(members))
+	for i, member := range members {
+		start := len(buf)
+		buf = append(buf, member...)
+		result[i] = buf[start:len(buf):len(buf)]
+	}
+	return result, nil
+}
+
+// forgetBytes invalidates the cached answer for a raw member, converting it
+// to a string only if the Set has a cache.
+func (s *Set) forgetBytes(b []byte) {
+	if s.cache != nil {
+		s.cache.forget(string(b))
+	}
+}
@@ -0,0 +1,53 @@
+package redisstringset
+
+import (
+	"bytes"
+	"slices"
+	"testing"
+)
+
+func TestBytesRoundTrip(t *testing.T) {
+	s, _ := newTestSet(t, "raw")
+	members := [][]byte{
+		{0x00},
+		{0x00, 0xff, 0x00},
+		{0xde, 0xad, 0xbe, 0xef},
+		[]byte("MiXeD"),
+		{},
+	}
+	for _, b := range members {
+		if err := s.InsertBytes(b); err != nil {
+			t.Fatalf("InsertBytes(%x): %v", b, err)
+		}
+	}
+	for _, b := range members {
+		if ok, err := s.HasBytes(b); !ok || err != nil {
+			t.Errorf("HasBytes(%x) = %v, %v", b, ok, err)
+		}
+	}
+	if ok, _ := s.Has("mixed"); ok {
+		t.Error("raw member was normalized")
+	}
+
+	got, err := s.SliceBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	slices.SortFunc(got, bytes.Compare)
+	want := slices.Clone(members)
+	slices.SortFunc(want, bytes.Compare)
+	if !slices.EqualFunc(got, want, bytes.Equal) {
+		t.Errorf("SliceBytes = %x, want %x", got, want)
+	}
+	got[0] = append(got[0], 'x')
+	if !bytes.Equal(got[1], want[1]) {
+		t.Errorf("appending to one member changed another: %x", got[1])
+	}
+
+	if err := s.RemoveBytes(members[1]); err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := s.HasBytes(members[1]); ok {
+		t.Errorf("HasBytes(%x) after RemoveBytes = true", members[1])
+	}
+}
@@ -0,0 +1,140 @@
+package redisstringset
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// WithLocalCache makes Has remember up to maxEntries recent answers, positive
+// and negative, for ttl, evicting the least recently used answer when full.
+// Inserts and removals through the Set invalidate the answers they affect, so
+// the Set always sees its own writes, but writes by other Sets or processes
+// sharing the key, and expiry of the key, may go unnoticed for up to ttl. Use
+// FlushLocalCache to discard every answer. Only Has consults the cache. The
+// cache is disabled if maxEntries or ttl is not positive.
+func WithLocalCache(maxEntries int, ttl time.Duration) Option {
+	return func(s *Set) {
+		s.cache = newMemberCache(maxEntries, ttl)
+	}
+}
+
+// FlushLocalCache discards every membership answer remembered because of
+// WithLocalCache. It does nothing for Sets without a cache.
+func (s *Set) FlushLocalCache() {
+	s.cache.flush()
+}
+
+// memberCache is an LRU cache of membership answers. A nil *memberCache is a
+// disabled cache: it remembers nothing.
+type memberCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	ttl        time.Duration
+	// generation is bumped by every invalidation so that an answer fetched
+	// before an invalidation is not stored after it.
+	generation uint64
+	entries    map[string]*list.Element
+	recent     *list.List
+}
+
+type cacheEntry struct {
+	member  string
+	present bool
+	expires time.Time
+}
+
+func newMemberCache(maxEntries int, ttl time.Duration) *memberCache {
+	if maxEntries < 1 || ttl <= 0 {
+		return nil
+	}
+	return &memberCache{
+		maxEntries: maxEntries,
+		ttl:        ttl,
+		entries:    make(map[string]*list.Element),
+		recent:     list.New(),
+	}
+}
+
+// clone returns an empty cache with the same limits.
+func (c *memberCache) clone() *memberCache {
+	if c == nil {
+		return nil
+	}
+	return newMemberCache(c.maxEntries, c.ttl)
+}
+
+// get returns the remembered answer for member, if it has not expired by now,
+// and the generation to pass to put after asking Redis instead.
+func (c *memberCache) get(member string, now time.Time) (present, ok bool, generation uint64) {
+	if c == nil {
+		return false, false, 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, found := c.entries[member]
+	if !found {
+		return false, false, c.generation
+	}
+	entry := elem.Value.(*cacheEntry)
+	if !now.Before(entry.expires) {
+		c.remove(elem)
+		return false, false, c.generation
+	}
+	c.recent.MoveToFront(elem)
+	return entry.present, true, c.generation
+}
+
+// put remembers the answer for member unless the cache was invalidated since
+// get returned generation.
+func (c *memberCache) put(member string, present bool, generation uint64, now time.Time) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if generation != c.generation {
+		return
+	}
+	entry := &cacheEntry{member: member, present: present, expires: now.Add(c.ttl)}
+	if elem, found := c.entries[member]; found {
+		elem.Value = entry
+		c.recent.MoveToFront(elem)
+		return
+	}
+	c.entries[member] = c.recent.PushFront(entry)
+	if c.recent.Len() > c.maxEntries {
+		c.remove(c.recent.Back())
+	}
+}
+
+// forget discards the answer for member.
+func (c *memberCache) forget(member string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.generation++
+	if elem, found := c.entries[member]; found {
+		c.remove(elem)
+	}
+}
+
+// flush discards every answer.
+func (c *memberCache) flush() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.generation++
+	clear(c.entries)
+	c.recent.Init()
+}
+
+// remove drops elem. The caller must hold c.mu.
+func (c *memberCache) remove(elem *list.Element) {
+	delete(c.entries, elem.Value.(*cacheEntry).member)
+	c.recent.Remove(elem)
+}
@@ -0,0 +1,93 @@
+package redisstringset
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLocalCache(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(0, 0)}
+	client, f, server := newFaultyClient(t)
+	s := newClientSet(t, client, "cached", WithLocalCache(2, time.Minute), WithClock(clock.now))
+	// has calls Has(element) and reports whether it asked Redis.
+	has := func(element string, want bool) (asked bool) {
+		t.Helper()
+		sent := f.count("sismember")
+		if got, err := s.Has(element); got != want || err != nil {
+			t.Errorf("Has(%q) = %v, %v, want %v", element, got, err, want)
+		}
+		return f.count("sismember") > sent
+	}
+
+	server.SAdd("cached", "a")
+	if !has("a", true) {
+		t.Error("first Has(a) did not ask Redis")
+	}
+	if has("A", true) || has("a", true) {
+		t.Error("cached Has(a) asked Redis")
+	}
+	if !has("b", false) || has("b", false) {
+		t.Error("negative answer not cached")
+	}
+
+	// Writes behind the Set's back go unnoticed until the answer expires.
+	server.SRem("cached", "a")
+	if has("a", true) {
+		t.Error("cached Has(a) asked Redis")
+	}
+	clock.advance(time.Minute)
+	if !has("a", false) {
+		t.Error("expired answer not refreshed")
+	}
+
+	// The Set's own writes invalidate the answers they affect.
+	if err := s.Insert("a"); err != nil {
+		t.Fatal(err)
+	}
+	if !has("a", true) {
+		t.Error("Has(a) after Insert did not ask Redis")
+	}
+	if err := s.Remove("a"); err != nil {
+		t.Fatal(err)
+	}
+	if !has("a", false) {
+		t.Error("Has(a) after Remove did not ask Redis")
+	}
+
+	s.FlushLocalCache()
+	if !has("a", false) {
+		t.Error("Has(a) after FlushLocalCache did not ask Redis")
+	}
+
+	// With room for two answers, the least recently used one is evicted.
+	has("b", false)
+	has("c", false)
+	if !has("a", false) {
+		t.Error("least recently used answer not evicted")
+	}
+	if has("c", false) {
+		t.Error("recently used answer evicted")
+	}
+}
+
+func TestLocalCacheDisabled(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		opts []Option
+	}{
+		{"no option", nil},
+		{"no entries", []Option{WithLocalCache(0, time.Minute)}},
+		{"no ttl", []Option{WithLocalCache(10, 0)}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			client, f, _ := newFaultyClient(t)
+			s := newClientSet(t, client, "uncached", tt.opts...)
+			s.FlushLocalCache()
+			s.Has("a")
+			s.Has("a")
+			if n := f.count("sismember"); n != 2 {
+				t.Errorf("two Has calls sent %d SISMEMBER, want 2", n)
+			}
+		})
+	}
+}
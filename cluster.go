@@ -0,0 +1,153 @@
+package redisstringset
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// clusterSlots is the number of hash slots in Redis Cluster.
+const clusterSlots = 16384
+
+// WithHashTag places the Set's key, and every key it is later given by Rename,
+// RenameNX, CopyTo and the Store methods, in the Redis Cluster hash slot of
+// tag by prefixing it with "{tag}:". Sets sharing a tag can then be combined
+// server-side on a cluster. Key returns the prefixed key.
+//
+// Sets created with a hash tag, like Sets using a *redis.ClusterClient, check
+// before each multi-key command that all keys involved hash to the same slot.
+// Sets using a *redis.Ring, which picks a shard by hash tag, check that the
+// keys have the same hash tag, or are the same key if they have none.
+// If they do not, set algebra such as Union, IntersectSlice or StoreDiff is
+// computed client-side as for Sets on different clients, while MoveTo, Swap,
+// Rename, RenameNX and CopyTo, which have no client-side equivalent, fail with
+// ErrCrossSlot instead of sending the command.
+func WithHashTag(tag string) Option {
+	return func(s *Set) {
+		s.hashTag = tag
+	}
+}
+
+// tagged returns key prefixed with the Set's hash tag, if it has one.
+func (s *Set) tagged(key string) string {
+	if s.hashTag == "" {
+		return key
+	}
+	return "{" + s.hashTag + "}:" + key
+}
+
+// serverSide reports whether an operation over the receiver and others can
+// run as a single command: they share a client and, on a cluster, a hash
+// slot. The caller must not hold any of their locks.
+func (s *Set) serverSide(others ...*Set) bool {
+	if !s.sharesClient(others...) {
+		return false
+	}
+	if !s.onCluster() {
+		return true
+	}
+	return s.sameSlot(append([]string{s.lockedKey()}, keysOf(others)...)...)
+}
+
+// onCluster reports whether keys must share a hash slot to be used together:
+// the Set has a hash tag, its client is a *redis.ClusterClient or a
+// *redis.Ring, or the server has rejected keys with CROSSSLOT before.
+func (s *Set) onCluster() bool {
+	if s.hashTag != "" || s.clustered.Load() {
+		return true
+	}
+	switch s.redisClient.(type) {
+	case *redis.ClusterClient, *redis.Ring:
+		return true
+	}
+	return false
+}
+
+// sameSlot reports whether keys can be used in a single command, which off a
+// cluster they always can. On a *redis.Ring they must share a hash tag, since
+// keys in the same slot may still be on different shards.
+func (s *Set) sameSlot(keys ...string) bool {
+	if !s.onCluster() || len(keys) == 0 {
+		return true
+	}
+	_, ring := s.redisClient.(*redis.Ring)
+	for _, key := range keys[1:] {
+		if keySlot(key) != keySlot(keys[0]) || ring && hashTagOf(key) != hashTagOf(keys[0]) {
+			return false
+		}
+	}
+	return true
+}
+
+// crossSlot reports whether err is a CROSSSLOT reply and, if so, records that
+// the Set is on a cluster so that later operations check slots up front.
+func (s *Set) crossSlot(err error) bool {
+	if !isCrossSlot(err) {
+		return false
+	}
+	s.clustered.Store(true)
+	return true
+}
+
+func isCrossSlot(err error) bool {
+	return hasReplyPrefix(err, "CROSSSLOT")
+}
+
+// checkSlots reports ErrCrossSlot if the Set is on a cluster and keys do not
+// all hash to the same slot.
+func (s *Set) checkSlots(keys ...string) error {
+	if s.sameSlot(keys...) {
+		return nil
+	}
+	for _, key := range keys[1:] {
+		if !s.sameSlot(keys[0], key) {
+			return fmt.Errorf("%w: %s is in slot %d, %s in slot %d", ErrCrossSlot, keys[0], keySlot(keys[0]), key, keySlot(key))
+		}
+	}
+	return nil
+}
+
+// keySlot returns the cluster hash slot of key, the CRC16 of its hashTagOf.
+func keySlot(key string) uint16 {
+	return crc16(hashTagOf(key)) % clusterSlots
+}
+
+// hashTagOf returns the part of key that Redis Cluster hashes: its hash tag,
+// the part between the first "{" and the next "}" if not empty, or else the
+// whole key.
+func hashTagOf(key string) string {
+	if start := strings.IndexByte(key, '{'); start >= 0 {
+		if end := strings.IndexByte(key[start+1:], '}'); end > 0 {
+			return key[start+1 : start+1+end]
+		}
+	}
+	return key
+}
+
+// sibling returns key with suffix appended, wrapping key in braces when needed
+// so that the result hashes to key's cluster slot. Keys containing a "}" but
+// no hash tag cannot be wrapped and get the suffix alone, so callers must
+// still check slots.
+func sibling(key, suffix string) string {
+	if hashTagOf(key) != key || strings.IndexByte(key, '}') >= 0 {
+		return key + suffix
+	}
+	return "{" + key + "}" + suffix
+}
+
+// crc16 is the CRC-16/XMODEM checksum used by Redis Cluster.
+func crc16(s string) uint16 {
+	var crc uint16
+	for i := 0; i < len(s); i++ {
+		crc ^= uint16(s[i]) << 8
+		for range 8 {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
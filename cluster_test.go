@@ -0,0 +1,98 @@
+package redisstringset
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// newMultiKeyClients returns a *redis.ClusterClient on a one-node miniredis
+// cluster and a *redis.Ring over two miniredis shards.
+func newMultiKeyClients(t *testing.T) map[string]redis.Cmdable {
+	t.Helper()
+	node := miniredis.RunT(t)
+	cluster := redis.NewClusterClient(&redis.ClusterOptions{Addrs: []string{node.Addr()}, MaxRetries: -1})
+	ring := redis.NewRing(&redis.RingOptions{
+		Addrs:      map[string]string{"a": miniredis.RunT(t).Addr(), "b": miniredis.RunT(t).Addr()},
+		MaxRetries: -1,
+	})
+	t.Cleanup(func() {
+		cluster.Close()
+		ring.Close()
+	})
+	return map[string]redis.Cmdable{"ClusterClient": cluster, "Ring": ring}
+}
+
+func TestMultiKeyClients(t *testing.T) {
+	for name, client := range newMultiKeyClients(t) {
+		t.Run(name, func(t *testing.T) {
+			s := newClientSet(t, client, "round-trip")
+			if err := s.InsertMany("a", "B", "c"); err != nil {
+				t.Fatal(err)
+			}
+			if err := s.Remove("c"); err != nil {
+				t.Fatal(err)
+			}
+			if ok, err := s.Has("b"); !ok || err != nil {
+				t.Errorf("Has(b) = %v, %v", ok, err)
+			}
+			if n, err := s.Len(); n != 2 || err != nil {
+				t.Errorf("Len = %d, %v, want 2", n, err)
+			}
+			got, err := s.Slice()
+			slices.Sort(got)
+			if want := []string{"a", "b"}; !slices.Equal(got, want) || err != nil {
+				t.Errorf("Slice = %v, %v, want %v", got, err, want)
+			}
+			if err := s.Destroy(); err != nil {
+				t.Errorf("Destroy: %v", err)
+			}
+		})
+	}
+}
+
+func TestMultiKeyClientsAcrossSlots(t *testing.T) {
+	for name, client := range newMultiKeyClients(t) {
+		t.Run(name, func(t *testing.T) {
+			// Untagged keys, in different slots and possibly on different
+			// Ring shards, are combined client-side.
+			left := newClientSet(t, client, "left")
+			right := newClientSet(t, client, "right")
+			left.InsertMany("a", "shared")
+			right.InsertMany("b", "shared")
+			if err := left.Union(right); err != nil {
+				t.Fatal(err)
+			}
+			got, _ := left.Slice()
+			slices.Sort(got)
+			if want := []string{"a", "b", "shared"}; !slices.Equal(got, want) {
+				t.Errorf("Union across slots = %v, want %v", got, want)
+			}
+			if _, err := left.MoveTo(right, "a"); !errors.Is(err, ErrCrossSlot) {
+				t.Errorf("MoveTo across slots = %v, want ErrCrossSlot", err)
+			}
+			if err := left.Rename("elsewhere"); !errors.Is(err, ErrCrossSlot) {
+				t.Errorf("Rename across slots = %v, want ErrCrossSlot", err)
+			}
+
+			// Tagged keys share a slot and a shard, so run server-side.
+			tagged := newClientSet(t, client, "left", WithHashTag("t"))
+			other := newClientSet(t, client, "right", WithHashTag("t"))
+			tagged.InsertMany("a", "shared")
+			other.InsertMany("b", "shared")
+			inter, err := tagged.IntersectSlice(other)
+			if want := []string{"shared"}; !slices.Equal(inter, want) || err != nil {
+				t.Errorf("IntersectSlice = %v, %v, want %v", inter, err, want)
+			}
+			if moved, err := tagged.MoveTo(other, "a"); !moved || err != nil {
+				t.Errorf("MoveTo within a slot = %v, %v", moved, err)
+			}
+			if err := tagged.Swap(other); err != nil {
+				t.Errorf("Swap within a slot: %v", err)
+			}
+		})
+	}
+}
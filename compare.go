@@ -0,0 +1,210 @@
+package redisstringset
+
+import (
+	"context"
+	"errors"
+)
+
+// Equal reports whether the receiver and the other Set have exactly the same
+// members, without modifying either. Their cardinalities are compared first.
+// When both Sets use the same Redis client the members are then compared with
+// a single SDIFF; otherwise the receiver is walked with SSCAN and its members
+// are checked against the other Set in SMISMEMBER batches.
+func (s *Set) Equal(other *Set) (bool, error) {
+	return s.EqualCtx(context.Background(), other)
+}
+
+// EqualCtx is like Equal but uses ctx for every Redis command.
+func (s *Set) EqualCtx(ctx context.Context, other *Set) (bool, error) {
+	if other == nil {
+		return false, ErrNilSet
+	}
+	n, err := s.LenCtx(ctx)
+	if err != nil {
+		return false, err
+	}
+	otherN, err := other.LenCtx(ctx)
+	if err != nil {
+		return false, err
+	}
+	if n != otherN {
+		return false, nil
+	}
+	return isSubset(ctx, s, other)
+}
+
+// IsSubsetOf reports whether every member of the receiver is in the other
+// Set, without modifying either; the empty Set is a subset of every Set. When
+// both Sets use the same Redis client this is a single SDIFF, which transfers
+// only the receiver's members missing from the other Set. Otherwise the
+// receiver is walked with SSCAN and checked against the other Set in
+// SMISMEMBER batches, stopping at the first missing member.
+func (s *Set) IsSubsetOf(other *Set) (bool, error) {
+	return s.IsSubsetOfCtx(context.Background(), other)
+}
+
+// IsSubsetOfCtx is like IsSubsetOf but uses ctx for every Redis command.
+func (s *Set) IsSubsetOfCtx(ctx context.Context, other *Set) (bool, error) {
+	if other == nil {
+		return false, ErrNilSet
+	}
+	return isSubset(ctx, s, other)
+}
+
+// IsSupersetOf reports whether every member of the other Set is in the
+// receiver; it is other.IsSubsetOf(s), with the same strategies.
+func (s *Set) IsSupersetOf(other *Set) (bool, error) {
+	return s.IsSupersetOfCtx(context.Background(), other)
+}
+
+// IsSupersetOfCtx is like IsSupersetOf but uses ctx for every Redis command.
+func (s *Set) IsSupersetOfCtx(ctx context.Context, other *Set) (bool, error) {
+	if other == nil {
+		return false, ErrNilSet
+	}
+	return isSubset(ctx, other, s)
+}
+
+// IsDisjointWith reports whether the receiver and the other Set have no
+// member in common, without modifying either; empty Sets are disjoint from
+// every Set. When both Sets use the same Redis client this is IntersectCard
+// with a limit of 1, so Redis 7 stops at the first common member while older
+// servers compute the whole intersection with SINTER. Otherwise the receiver
+// is walked with SSCAN and checked against the other Set in SMISMEMBER
+// batches, stopping at the first common member.
+func (s *Set) IsDisjointWith(other *Set) (bool, error) {
+	return s.IsDisjointWithCtx(context.Background(), other)
+}
+
+// IsDisjointWithCtx is like IsDisjointWith but uses ctx for every Redis
+// command.
+func (s *Set) IsDisjointWithCtx(ctx context.Context, other *Set) (bool, error) {
+	if other == nil {
+		return false, ErrNilSet
+	}
+	if s.serverSide(other) {
+		n, err := s.IntersectCardCtx(ctx, other, 1)
+		if err != nil {
+			return false, err
+		}
+		return n == 0, nil
+	}
+	common, err := anyAgainst(ctx, s, other, true)
+	if err != nil {
+		return false, err
+	}
+	return !common, nil
+}
+
+// Fingerprint returns a 64-bit digest of the Set's membership, for comparing
+// Sets that cannot be compared with Equal, such as copies in different
+// clusters. Members are fetched with SSCAN as Each does, hashed individually
+// and the hashes summed, so the result does not depend on iteration order and
+// Sets with the same members always have the same fingerprint. Members SSCAN
+// returns more than once are hashed once. Different Sets collide with a
+// probability of about 2^-64, but the hash is not cryptographic and collisions
+// can be constructed deliberately. The fingerprint is only meaningful if the
+// Set is not modified during the call, since SSCAN may then skip members.
+func (s *Set) Fingerprint() (uint64, error) {
+	return s.FingerprintCtx(context.Background())
+}
+
+// FingerprintCtx is like Fingerprint but uses ctx for every Redis command.
+func (s *Set) FingerprintCtx(ctx context.Context) (uint64, error) {
+	var sum uint64
+	seen := make(map[string]nothing)
+	err := s.Each(ctx, func(member string) error {
+		if _, ok := seen[member]; !ok {
+			seen[member] = nothing{}
+			sum += memberHash(member)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return sum, nil
+}
+
+// memberHash is FNV-1a followed by the SplitMix64 finalizer, which spreads
+// FNV's weak high bits so that sums of hashes stay well distributed.
+func memberHash(member string) uint64 {
+	h := uint64(14695981039346656037)
+	for i := 0; i < len(member); i++ {
+		h ^= uint64(member[i])
+		h *= 1099511628211
+	}
+	h ^= h >> 30
+	h *= 0xbf58476d1ce4e5b9
+	h ^= h >> 27
+	h *= 0x94d049bb133111eb
+	h ^= h >> 31
+	return h
+}
+
+// isSubset reports whether every member of sub is in super. Failures are
+// recorded by the Set whose command failed.
+func isSubset(ctx context.Context, sub, super *Set) (bool, error) {
+	if sub.serverSide(super) {
+		diff, err := sub.combineOthers(ctx, "difference", sub.redisClient.SDiff, []*Set{super})
+		if !isCrossSlot(err) {
+			if err != nil {
+				return false, err
+			}
+			return len(diff) == 0, nil
+		}
+	}
+	missing, err := anyAgainst(ctx, sub, super, false)
+	if err != nil {
+		return false, err
+	}
+	return !missing, nil
+}
+
+// anyAgainst walks s with SSCAN and reports whether any of its members has
+// membership present in other, checking them in SMISMEMBER batches and
+// stopping at the first such member. Failures are recorded by the Set whose
+// command failed.
+func anyAgainst(ctx context.Context, s, other *Set, present bool) (bool, error) {
+	found := false
+	batch := make([]string, 0, other.batchSize)
+	check := func() error {
+		ok, err := other.anyMembership(ctx, batch, present)
+		batch = batch[:0]
+		if err == nil && ok {
+			found = true
+			return errStopIteration
+		}
+		return err
+	}
+	err := s.Each(ctx, func(member string) error {
+		if batch = append(batch, member); len(batch) < cap(batch) {
+			return nil
+		}
+		return check()
+	})
+	if err == nil && len(batch) > 0 {
+		err = check()
+	}
+	if err != nil && !errors.Is(err, errStopIteration) {
+		return false, err
+	}
+	return found, nil
+}
+
+// anyMembership reports whether any of the already normalized members has
+// membership present in the Set, stopping at the first. The caller must not
+// hold the lock.
+func (s *Set) anyMembership(ctx context.Context, members []string, present bool) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	found := false
+	err := s.eachMembership(ctx, members, func(_ int, ok bool) bool {
+		found = ok == present
+		return !found
+	})
+	if err != nil {
+		return false, err
+	}
+	return found, nil
+}
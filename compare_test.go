@@ -0,0 +1,125 @@
+package redisstringset
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		name string
+		run  func(s, other *Set) (bool, error)
+		want map[string]bool
+	}{
+		{"Equal", (*Set).Equal, map[string]bool{
+			"identical": true,
+		}},
+		{"IsSubsetOf", (*Set).IsSubsetOf, map[string]bool{
+			"identical":      true,
+			"subset":         true,
+			"empty receiver": true,
+		}},
+		{"IsSupersetOf", (*Set).IsSupersetOf, map[string]bool{
+			"identical":   true,
+			"superset":    true,
+			"empty other": true,
+		}},
+		{"IsDisjointWith", (*Set).IsDisjointWith, map[string]bool{
+			"disjoint":       true,
+			"empty receiver": true,
+			"empty other":    true,
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runPairs(t, func(t *testing.T, left, right *Set, pair string) {
+				if got, err := tt.run(left, right); got != tt.want[pair] || err != nil {
+					t.Errorf("%s = %v, %v, want %v", tt.name, got, err, tt.want[pair])
+				}
+			})
+			s, _ := newTestSet(t, "left")
+			if _, err := tt.run(s, nil); !errors.Is(err, ErrNilSet) {
+				t.Errorf("%s(nil) = %v, want ErrNilSet", tt.name, err)
+			}
+		})
+	}
+
+	// Walks spanning several batches of the other Set.
+	client, _ := newTestClient(t)
+	elsewhere, _ := newTestClient(t)
+	left := newClientSet(t, client, "left")
+	right := newClientSet(t, elsewhere, "right", WithBatchSize(2))
+	left.InsertMany("a", "b", "c", "d", "e")
+	right.InsertMany("a", "b", "c", "d", "e", "f")
+	if ok, err := left.IsSubsetOf(right); !ok || err != nil {
+		t.Errorf("IsSubsetOf across batches = %v, %v, want true", ok, err)
+	}
+	right.Remove("e")
+	if ok, err := left.IsSubsetOf(right); ok || err != nil {
+		t.Errorf("IsSubsetOf missing the last member = %v, %v, want false", ok, err)
+	}
+	if ok, err := left.IsDisjointWith(right); ok || err != nil {
+		t.Errorf("IsDisjointWith across batches = %v, %v, want false", ok, err)
+	}
+}
+
+// repeatHook is a go-redis hook making every SSCAN reply list its page twice,
+// as SSCAN may repeat members of a Set being rehashed.
+type repeatHook struct{}
+
+func (repeatHook) DialHook(next redis.DialHook) redis.DialHook { return next }
+
+func (repeatHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		err := next(ctx, cmd)
+		if c, ok := cmd.(*redis.ScanCmd); ok && err == nil && cmd.Name() == "sscan" {
+			page, cursor := c.Val()
+			c.SetVal(append(page, page...), cursor)
+		}
+		return err
+	}
+}
+
+func (repeatHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return next
+}
+
+func TestFingerprint(t *testing.T) {
+	fingerprint := func(s *Set) uint64 {
+		t.Helper()
+		fp, err := s.Fingerprint()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return fp
+	}
+	left, _ := newTestSet(t, "left", WithScanCount(2))
+	right, _ := newTestSet(t, "right")
+	left.InsertMany("a", "b", "c", "d", "e")
+	right.InsertMany("e", "d", "c", "b", "a")
+	want := fingerprint(left)
+	if got := fingerprint(right); got != want {
+		t.Errorf("fingerprints of equal Sets = %#x and %#x", want, got)
+	}
+
+	repeating, _ := newTestClient(t)
+	repeating.AddHook(repeatHook{})
+	repeated := newClientSet(t, repeating, "repeated", WithScanCount(2))
+	repeated.InsertMany("a", "b", "c", "d", "e")
+	if got := fingerprint(repeated); got != want {
+		t.Errorf("fingerprint with repeated SSCAN members = %#x, want %#x", got, want)
+	}
+
+	right.Remove("e")
+	right.Insert("f")
+	if got := fingerprint(right); got == want {
+		t.Errorf("fingerprint unchanged at %#x after replacing a member", got)
+	}
+	empty, _ := newTestSet(t, "empty")
+	if got := fingerprint(empty); got != 0 {
+		t.Errorf("fingerprint of an empty Set = %#x, want 0", got)
+	}
+}
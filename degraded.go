@@ -0,0 +1,217 @@
+package redisstringset
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// WithDegradedMode makes the Set keep working, best effort, while Redis is
+// unreachable. When Insert, InsertMany or Has fails with ErrUnavailable,
+// ErrTimeout or ErrCircuitOpen, the Set enters degraded mode: the failed
+// inserts are recorded in a local map of up to maxEntries members and succeed,
+// and Has answers from that map, so it only knows about members inserted
+// through this Set during the outage. Inserts past maxEntries are dropped and
+// counted. The first command to reach Redis again replays the recorded members
+// with batched SADD commands before returning, leaving degraded mode once all
+// of them are sent. That command runs before the replay, so unlike Has, a read
+// such as Len or Slice triggering it does not yet see the replayed members.
+// Remove, RemoveMany, Clear and the other operations still fail as usual,
+// though removals forget the members from the local map. Pair it with
+// WithCircuitBreaker so that degraded calls do not each wait for a timeout.
+// Failures met in degraded mode are still logged and recorded for Err.
+// Degraded mode is disabled if maxEntries is not positive.
+func WithDegradedMode(maxEntries int) Option {
+	return func(s *Set) {
+		s.fallback = newFallback(maxEntries)
+	}
+}
+
+// DegradedStats describes a Set's degraded mode, see WithDegradedMode.
+type DegradedStats struct {
+	// Degraded reports whether the Set is in degraded mode.
+	Degraded bool
+	// Pending is the number of members recorded locally awaiting replay.
+	Pending int
+	// Dropped is the number of inserts discarded because the local map was
+	// full.
+	Dropped uint64
+	// Replayed is the number of members sent to Redis by replays.
+	Replayed uint64
+}
+
+// DegradedStats returns the current state of the Set's degraded mode. It is
+// the zero DegradedStats for Sets without degraded mode.
+func (s *Set) DegradedStats() DegradedStats {
+	return s.fallback.stats()
+}
+
+// degrade reports whether err from inserting or checking members allows the
+// Set to proceed in degraded mode, recording members locally if so.
+func (s *Set) degrade(err error, members ...string) bool {
+	if s.fallback == nil || !(retryable(err) || errors.Is(err, ErrCircuitOpen)) {
+		return false
+	}
+	if s.fallback.record(members) {
+		s.logger.Printf("Redis unavailable, %s entering degraded mode: %v", s.key, err)
+	}
+	return true
+}
+
+// replay sends the members recorded in degraded mode to Redis, stopping at
+// the first failure. Only one replay runs at a time. The caller must hold the
+// lock.
+func (s *Set) replay(ctx context.Context) {
+	members := s.fallback.take()
+	if members == nil {
+		return
+	}
+	defer s.cache.flush()
+	for start := 0; start < len(members); start += s.batchSize {
+		chunk := members[start:min(start+s.batchSize, len(members))]
+		if _, err := s.batch(ctx, redis.Cmdable.SAdd, chunk); err != nil {
+			s.logger.Printf("Error replaying %d members into %s: %v", len(members)-start, s.key, err)
+			s.fallback.finish(false)
+			return
+		}
+		s.fallback.replayed(chunk)
+	}
+	s.fallback.finish(true)
+}
+
+// fallback is the local membership map of degraded mode. A nil *fallback is
+// disabled degraded mode: it records nothing.
+type fallback struct {
+	mu         sync.Mutex
+	maxEntries int
+	degraded   bool
+	replaying  bool
+	members    map[string]struct{}
+	dropped    uint64
+	sent       uint64
+}
+
+func newFallback(maxEntries int) *fallback {
+	if maxEntries < 1 {
+		return nil
+	}
+	return &fallback{maxEntries: maxEntries, members: make(map[string]struct{})}
+}
+
+// clone returns an empty fallback with the same limit.
+func (f *fallback) clone() *fallback {
+	if f == nil {
+		return nil
+	}
+	return newFallback(f.maxEntries)
+}
+
+// record enters degraded mode and adds members, dropping those that do not
+// fit. It reports whether the Set was not already degraded.
+func (f *fallback) record(members []string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	entered := !f.degraded
+	f.degraded = true
+	for _, member := range members {
+		if _, ok := f.members[member]; ok {
+			continue
+		}
+		if len(f.members) >= f.maxEntries {
+			f.dropped++
+			continue
+		}
+		f.members[member] = struct{}{}
+	}
+	return entered
+}
+
+// has reports whether member was recorded and not yet replayed.
+func (f *fallback) has(member string) bool {
+	if f == nil {
+		return false
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	_, ok := f.members[member]
+	return ok
+}
+
+// forget removes members from the map.
+func (f *fallback) forget(members ...string) {
+	if f == nil {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, member := range members {
+		delete(f.members, member)
+	}
+}
+
+// flush removes every member from the map.
+func (f *fallback) flush() {
+	if f == nil {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	clear(f.members)
+}
+
+// take returns the recorded members to replay, or nil if the Set is not
+// degraded or a replay is already running. A non-nil result must be followed
+// by a call to finish.
+func (f *fallback) take() []string {
+	if f == nil {
+		return nil
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !f.degraded || f.replaying {
+		return nil
+	}
+	f.replaying = true
+	members := make([]string, 0, len(f.members))
+	for member := range f.members {
+		members = append(members, member)
+	}
+	return members
+}
+
+// replayed removes members sent by a replay from the map.
+func (f *fallback) replayed(members []string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, member := range members {
+		delete(f.members, member)
+	}
+	f.sent += uint64(len(members))
+}
+
+// finish ends a replay, leaving degraded mode if it succeeded and nothing was
+// recorded meanwhile.
+func (f *fallback) finish(ok bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.replaying = false
+	if ok && len(f.members) == 0 {
+		f.degraded = false
+	}
+}
+
+func (f *fallback) stats() DegradedStats {
+	if f == nil {
+		return DegradedStats{}
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return DegradedStats{
+		Degraded: f.degraded,
+		Pending:  len(f.members),
+		Dropped:  f.dropped,
+		Replayed: f.sent,
+	}
+}
@@ -0,0 +1,103 @@
+package redisstringset
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+// TestDegradedMode walks a Set through an outage, each step failing the listed
+// commands with errRefused while the others reach the server.
+func TestDegradedMode(t *testing.T) {
+	done := func(err error) (bool, error) { return true, err }
+	steps := []struct {
+		name  string
+		fail  []string
+		run   func(s *Set) (bool, error)
+		want  bool
+		err   error
+		stats DegradedStats
+	}{
+		{"insert is recorded", []string{"sadd", "sismember", "srem"}, func(s *Set) (bool, error) {
+			return done(s.Insert("a"))
+		}, true, nil, DegradedStats{Degraded: true, Pending: 1}},
+		{"bulk insert past the cap drops", []string{"sadd", "sismember", "srem"}, func(s *Set) (bool, error) {
+			return done(s.InsertMany("b", "c", "d"))
+		}, true, nil, DegradedStats{Degraded: true, Pending: 3, Dropped: 1}},
+		{"has answers from the map", []string{"sadd", "sismember", "srem"}, func(s *Set) (bool, error) {
+			return s.Has("a")
+		}, true, nil, DegradedStats{Degraded: true, Pending: 3, Dropped: 1}},
+		{"dropped members are unknown", []string{"sadd", "sismember", "srem"}, func(s *Set) (bool, error) {
+			return s.Has("d")
+		}, false, nil, DegradedStats{Degraded: true, Pending: 3, Dropped: 1}},
+		{"remove fails but forgets", []string{"sadd", "sismember", "srem"}, func(s *Set) (bool, error) {
+			return done(s.Remove("b"))
+		}, true, ErrUnavailable, DegradedStats{Degraded: true, Pending: 2, Dropped: 1}},
+		{"failed replay keeps the map", []string{"sadd"}, func(s *Set) (bool, error) {
+			return s.Has("a")
+		}, true, nil, DegradedStats{Degraded: true, Pending: 2, Dropped: 1}},
+		{"recovery replays", nil, func(s *Set) (bool, error) {
+			return s.Has("c")
+		}, true, nil, DegradedStats{Dropped: 1, Replayed: 2}},
+		{"recovered", nil, func(s *Set) (bool, error) {
+			return s.Has("b")
+		}, false, nil, DegradedStats{Dropped: 1, Replayed: 2}},
+	}
+
+	client, f, server := newFaultyClient(t)
+	s := newClientSet(t, client, "degraded", WithDegradedMode(3))
+	for _, step := range steps {
+		f.heal()
+		for _, cmd := range step.fail {
+			f.inject(cmd, -1, errRefused)
+		}
+		got, err := step.run(s)
+		if got != step.want || !errors.Is(err, step.err) || (err == nil) != (step.err == nil) {
+			t.Errorf("%s: got %v, %v, want %v, %v", step.name, got, err, step.want, step.err)
+		}
+		if stats := s.DegradedStats(); stats != step.stats {
+			t.Errorf("%s: DegradedStats = %+v, want %+v", step.name, stats, step.stats)
+		}
+	}
+	if got, want := mustMembers(t, server, "degraded"), []string{"a", "c"}; !slices.Equal(got, want) {
+		t.Errorf("members after replay = %v, want %v", got, want)
+	}
+	if !errors.Is(s.Err(), ErrUnavailable) {
+		t.Errorf("Err = %v, want the failures met while degraded", s.Err())
+	}
+}
+
+// TestDegradedModeWithBreaker checks that an open breaker also degrades, and
+// that the probe closing it replays the recorded members.
+func TestDegradedModeWithBreaker(t *testing.T) {
+	clock := &fakeClock{}
+	client, f, server := newFaultyClient(t)
+	s := newClientSet(t, client, "degraded", WithDegradedMode(10),
+		WithCircuitBreaker(1, time.Minute), WithClock(clock.now))
+
+	f.inject("sadd", -1, errRefused)
+	for _, member := range []string{"a", "b"} {
+		if err := s.Insert(member); err != nil {
+			t.Fatalf("Insert(%s) = %v while degraded", member, err)
+		}
+	}
+	if n := f.count("sadd"); n != 1 {
+		t.Errorf("sent sadd %d times, want 1 before the breaker opened", n)
+	}
+	f.heal()
+	clock.advance(time.Minute)
+	// The probe itself runs before the replay it triggers.
+	if n, err := s.Len(); n != 0 || err != nil {
+		t.Errorf("Len probing = %d, %v, want 0", n, err)
+	}
+	if n, err := s.Len(); n != 2 || err != nil {
+		t.Errorf("Len after the replay = %d, %v, want 2", n, err)
+	}
+	if got, want := mustMembers(t, server, "degraded"), []string{"a", "b"}; !slices.Equal(got, want) {
+		t.Errorf("members after replay = %v, want %v", got, want)
+	}
+	if stats := s.DegradedStats(); stats != (DegradedStats{Replayed: 2}) {
+		t.Errorf("DegradedStats = %+v", stats)
+	}
+}
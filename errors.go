@@ -0,0 +1,133 @@
+package redisstringset
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	// ErrUnavailable reports that Redis could not be reached or is temporarily
+	// unable to serve requests. Such failures are usually worth retrying.
+	ErrUnavailable = errors.New("redis unavailable")
+
+	// ErrWrongType reports that the key exists but does not hold a set.
+	ErrWrongType = errors.New("key does not hold a set")
+
+	// ErrTimeout reports that a Redis round trip did not finish before its
+	// deadline, whether set by the caller's context or by WithTimeout.
+	ErrTimeout = errors.New("redis operation timed out")
+
+	// ErrClosed reports that the underlying Redis client has been closed.
+	ErrClosed = errors.New("redis client closed")
+
+	// ErrLocked reports that a Set created with WithDistributedLock could not
+	// acquire its lock because another client holds it.
+	ErrLocked = errors.New("set is locked by another client")
+
+	// ErrNilSet reports a nil *Set passed to an operation involving several Sets.
+	ErrNilSet = errors.New("nil Set")
+
+	// ErrDifferentClients reports an operation that must run atomically on a
+	// single Redis client but was given Sets using different clients.
+	ErrDifferentClients = errors.New("sets use different redis clients")
+
+	// ErrKeyExists reports that the destination key of an operation already
+	// exists and the operation was asked not to, or never does, replace it.
+	ErrKeyExists = errors.New("destination key already exists")
+
+	// ErrCrossSlot reports a multi-key operation without a client-side
+	// fallback, such as MoveTo, whose keys do not all hash to the same Redis
+	// Cluster slot, detected up front as described for WithHashTag or
+	// reported by the server as CROSSSLOT.
+	ErrCrossSlot = errors.New("keys hash to different cluster slots")
+
+	// ErrEmptyElement reports an attempt to insert an empty element into a
+	// Set created with RejectEmpty.
+	ErrEmptyElement = errors.New("empty element")
+
+	// ErrCircuitOpen reports a command not sent because the Set's
+	// CircuitBreaker is open after repeated failures to reach Redis.
+	ErrCircuitOpen = errors.New("circuit breaker open")
+)
+
+// BatchError reports a bulk operation that failed part way through. The
+// members sent before the failing command were applied.
+type BatchError struct {
+	// Processed is the number of members sent successfully before the failure.
+	Processed int
+	Err       error
+}
+
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("%v (after %d members)", e.Err, e.Processed)
+}
+
+func (e *BatchError) Unwrap() error {
+	return e.Err
+}
+
+// classify wraps err with the sentinel matching its failure mode, so both the
+// sentinel and the original go-redis error are reachable via errors.Is and
+// errors.As. Errors matching no sentinel are returned unchanged.
+func classify(err error) error {
+	var sentinel error
+	switch {
+	case errors.Is(err, context.Canceled):
+		return err
+	case isTimeout(err):
+		sentinel = ErrTimeout
+	case errors.Is(err, redis.ErrClosed):
+		sentinel = ErrClosed
+	case hasReplyPrefix(err, "WRONGTYPE"):
+		sentinel = ErrWrongType
+	case hasReplyPrefix(err, "CROSSSLOT"):
+		sentinel = ErrCrossSlot
+	case isUnavailable(err):
+		sentinel = ErrUnavailable
+	default:
+		return err
+	}
+	return fmt.Errorf("%w: %w", sentinel, err)
+}
+
+func isTimeout(err error) bool {
+	// context.DeadlineExceeded itself satisfies net.Error with Timeout true.
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+func isUnavailable(err error) bool {
+	var netErr net.Error
+	if errors.As(err, &netErr) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	for _, prefix := range []string{"LOADING", "MASTERDOWN", "CLUSTERDOWN", "TRYAGAIN"} {
+		if hasReplyPrefix(err, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// isUnknownCommand reports whether err is the server rejecting a command it
+// does not implement, as older Redis versions do for newer commands, or the
+// client being unable to send it at all.
+func isUnknownCommand(err error) bool {
+	if errors.Is(err, errNoDo) {
+		return true
+	}
+	var replyErr redis.Error
+	return errors.As(err, &replyErr) && strings.HasPrefix(replyErr.Error(), "ERR unknown command")
+}
+
+// hasReplyPrefix reports whether err is a Redis error reply starting with prefix.
+func hasReplyPrefix(err error, prefix string) bool {
+	var replyErr redis.Error
+	return errors.As(err, &replyErr) && strings.HasPrefix(replyErr.Error(), prefix+" ")
+}
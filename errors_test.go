@@ -0,0 +1,71 @@
+package redisstringset
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{"connection refused", errRefused, ErrUnavailable},
+		{"wrong type", replyError("WRONGTYPE Operation against a key holding the wrong kind of value"), ErrWrongType},
+		{"cross slot", replyError("CROSSSLOT Keys in request don't hash to the same slot"), ErrCrossSlot},
+		{"loading", replyError("LOADING Redis is loading the dataset in memory"), ErrUnavailable},
+		{"deadline", context.DeadlineExceeded, ErrTimeout},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := classify(tt.err)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("classify(%v) = %v, want %v", tt.err, err, tt.want)
+			}
+			if !errors.Is(err, tt.err) {
+				t.Errorf("classify(%v) = %v, lost the original error", tt.err, err)
+			}
+		})
+	}
+	if err := classify(context.Canceled); err != context.Canceled {
+		t.Errorf("classify(context.Canceled) = %v, want it unchanged", err)
+	}
+}
+
+func TestErrorsFromServer(t *testing.T) {
+	s, server := newTestSet(t, "classified")
+	server.Set("classified", "not a set")
+	if _, err := s.Has("a"); !errors.Is(err, ErrWrongType) {
+		t.Errorf("Has on a string key = %v, want ErrWrongType", err)
+	}
+
+	server.Close()
+	if err := s.Insert("a"); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("Insert with the server down = %v, want ErrUnavailable", err)
+	}
+}
+
+func TestScanDeadlineIsTimeout(t *testing.T) {
+	s, _ := newTestSet(t, "scanned")
+	s.InsertMany("a", "b", "c")
+	ctx, cancel := context.WithTimeout(context.Background(), time.Nanosecond)
+	defer cancel()
+	<-ctx.Done()
+
+	if err := s.Each(ctx, func(string) error { return nil }); !errors.Is(err, ErrTimeout) {
+		t.Errorf("Each = %v, want ErrTimeout", err)
+	}
+	if _, err := s.scanMembers(ctx, 0); !errors.Is(err, ErrTimeout) {
+		t.Errorf("scanMembers = %v, want ErrTimeout", err)
+	}
+
+	streamCtx, cancelStream := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancelStream()
+	_, errc := s.Stream(streamCtx, 0)
+	if err := <-errc; !errors.Is(err, ErrTimeout) {
+		t.Errorf("Stream abandoned past its deadline = %v, want ErrTimeout", err)
+	}
+}
@@ -0,0 +1,228 @@
+package redisstringset
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ExpiringSet is a set of strings whose members expire individually. It is
+// stored in Redis as a sorted set scored by each member's expiry time in Unix
+// milliseconds. Expired members are invisible to Has, Slice and Len as soon
+// as they expire but keep using memory until Prune removes them.
+//
+// An ExpiringSet accepts the same Options as a Set, including normalization,
+// WithTimeout and WithClock, and is safe for concurrent use.
+type ExpiringSet struct {
+	s   *Set
+	ttl time.Duration
+}
+
+// NewExpiring returns an ExpiringSet backed by the sorted set at key on
+// redisClient whose members expire ttl after they were last inserted unless
+// InsertTTL says otherwise. Like NewWithOptions it checks that Redis is
+// reachable.
+func NewExpiring(redisClient redis.Cmdable, key string, ttl time.Duration, opts ...Option) (*ExpiringSet, error) {
+	s, err := NewWithOptions(redisClient, key, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &ExpiringSet{s: s, ttl: ttl}, nil
+}
+
+// WithClock makes an ExpiringSet read the current time from now instead of
+// time.Now, for instance to control expiry in tests. Every process sharing an
+// ExpiringSet's key must agree on the time. Plain Sets use it only to expire
+// the answers of WithLocalCache and to time their CircuitBreaker's cooldown.
+func WithClock(now func() time.Time) Option {
+	return func(s *Set) {
+		if now != nil {
+			s.now = now
+		}
+	}
+}
+
+// Insert adds element, or extends its lifetime, so that it expires after the
+// ExpiringSet's default TTL.
+func (e *ExpiringSet) Insert(element string) error {
+	return e.InsertTTLCtx(context.Background(), element, e.ttl)
+}
+
+// InsertCtx is like Insert but uses ctx for the Redis command.
+func (e *ExpiringSet) InsertCtx(ctx context.Context, element string) error {
+	return e.InsertTTLCtx(ctx, element, e.ttl)
+}
+
+// InsertTTL is like Insert but makes element expire after ttl. The new expiry
+// replaces any previous one, even if it is sooner.
+func (e *ExpiringSet) InsertTTL(element string, ttl time.Duration) error {
+	return e.InsertTTLCtx(context.Background(), element, ttl)
+}
+
+// InsertTTLCtx is like InsertTTL but uses ctx for the Redis command.
+func (e *ExpiringSet) InsertTTLCtx(ctx context.Context, element string, ttl time.Duration) error {
+	s := e.s
+	member, ok, err := s.insertable(element)
+	if err != nil || !ok {
+		return err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	expiry := float64(s.now().Add(ttl).UnixMilli())
+	if err := s.redisClient.ZAdd(ctx, s.key, redis.Z{Score: expiry, Member: member}).Err(); err != nil {
+		s.logger.Printf("Error inserting %s into %s: %v", member, s.key, err)
+		return s.fail(fmt.Errorf("inserting %s into %s: %w", member, s.key, classify(err)))
+	}
+	return nil
+}
+
+// Has reports whether element is a member that has not expired.
+func (e *ExpiringSet) Has(element string) (bool, error) {
+	return e.HasCtx(context.Background(), element)
+}
+
+// HasCtx is like Has but uses ctx for the Redis command.
+func (e *ExpiringSet) HasCtx(ctx context.Context, element string) (bool, error) {
+	s := e.s
+	member, ok := s.normalize(element)
+	if !ok {
+		return false, nil
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	expiry, err := s.redisClient.ZScore(ctx, s.key, member).Result()
+	if errors.Is(err, redis.Nil) {
+		return false, nil
+	}
+	if err != nil {
+		s.logger.Printf("Error checking membership for %s: %v", member, err)
+		return false, s.fail(fmt.Errorf("checking membership for %s in %s: %w", member, s.key, classify(err)))
+	}
+	return expiry > float64(s.now().UnixMilli()), nil
+}
+
+// Remove deletes element, whether or not it has expired.
+func (e *ExpiringSet) Remove(element string) error {
+	return e.RemoveCtx(context.Background(), element)
+}
+
+// RemoveCtx is like Remove but uses ctx for the Redis command.
+func (e *ExpiringSet) RemoveCtx(ctx context.Context, element string) error {
+	s := e.s
+	member, ok := s.normalize(element)
+	if !ok {
+		return nil
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	if err := s.redisClient.ZRem(ctx, s.key, member).Err(); err != nil {
+		s.logger.Printf("Error removing %s from %s: %v", member, s.key, err)
+		return s.fail(fmt.Errorf("removing %s from %s: %w", member, s.key, classify(err)))
+	}
+	return nil
+}
+
+// Slice returns the members that have not expired, soonest to expire first.
+// An empty ExpiringSet yields a non-nil empty slice.
+func (e *ExpiringSet) Slice() ([]string, error) {
+	return e.SliceCtx(context.Background())
+}
+
+// SliceCtx is like Slice but uses ctx for the Redis command.
+func (e *ExpiringSet) SliceCtx(ctx context.Context) ([]string, error) {
+	s := e.s
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	result, err := s.redisClient.ZRangeByScore(ctx, s.key, &redis.ZRangeBy{
+		Min: e.liveMin(),
+		Max: "+inf",
+	}).Result()
+	if err != nil {
+		s.logger.Printf("Error retrieving members for %s: %v", s.key, err)
+		return nil, s.fail(fmt.Errorf("retrieving members for %s: %w", s.key, classify(err)))
+	}
+	if result == nil {
+		result = []string{}
+	}
+	return result, nil
+}
+
+// Len returns the number of members that have not expired.
+func (e *ExpiringSet) Len() (int, error) {
+	return e.LenCtx(context.Background())
+}
+
+// LenCtx is like Len but uses ctx for the Redis command.
+func (e *ExpiringSet) LenCtx(ctx context.Context) (int, error) {
+	s := e.s
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	n, err := s.redisClient.ZCount(ctx, s.key, e.liveMin(), "+inf").Result()
+	if err != nil {
+		s.logger.Printf("Error getting length of %s: %v", s.key, err)
+		return 0, s.fail(fmt.Errorf("getting length of %s: %w", s.key, classify(err)))
+	}
+	if n > math.MaxInt {
+		return 0, s.fail(fmt.Errorf("length of %s overflows int: %d", s.key, n))
+	}
+	return int(n), nil
+}
+
+// Prune deletes the expired members with ZREMRANGEBYSCORE and returns how many
+// there were. Call it periodically to reclaim their memory.
+func (e *ExpiringSet) Prune() (int, error) {
+	return e.PruneCtx(context.Background())
+}
+
+// PruneCtx is like Prune but uses ctx for the Redis command.
+func (e *ExpiringSet) PruneCtx(ctx context.Context) (int, error) {
+	s := e.s
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	now := strconv.FormatInt(s.now().UnixMilli(), 10)
+	n, err := s.redisClient.ZRemRangeByScore(ctx, s.key, "-inf", now).Result()
+	if err != nil {
+		s.logger.Printf("Error pruning %s: %v", s.key, err)
+		return 0, s.fail(fmt.Errorf("pruning %s: %w", s.key, classify(err)))
+	}
+	if n > math.MaxInt {
+		return 0, s.fail(fmt.Errorf("number of members pruned from %s overflows int: %d", s.key, n))
+	}
+	return int(n), nil
+}
+
+// Err returns the first error encountered by the ExpiringSet, as Set.Err does.
+func (e *ExpiringSet) Err() error {
+	return e.s.Err()
+}
+
+// ResetErr clears the error reported by Err.
+func (e *ExpiringSet) ResetErr() {
+	e.s.ResetErr()
+}
+
+// Key returns the Redis key of the sorted set backing the ExpiringSet.
+func (e *ExpiringSet) Key() string {
+	return e.s.key
+}
+
+// Client returns the Redis client the ExpiringSet was created with.
+func (e *ExpiringSet) Client() redis.Cmdable {
+	return e.s.redisClient
+}
+
+// liveMin is the exclusive lower score bound of members that have not expired.
+func (e *ExpiringSet) liveMin() string {
+	return "(" + strconv.FormatInt(e.s.now().UnixMilli(), 10)
+}
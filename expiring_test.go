@@ -0,0 +1,85 @@
+package redisstringset
+
+import (
+	"math"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestExpiringSet(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(1000, 0)}
+	client, server := newTestClient(t)
+	e, err := NewExpiring(client, "expiring", time.Minute, WithLogger(nil), WithClock(clock.now))
+	if err != nil {
+		t.Fatal(err)
+	}
+	check := func(when string, want []string) {
+		t.Helper()
+		if got, err := e.Slice(); !slices.Equal(got, want) || err != nil {
+			t.Errorf("Slice %s = %q, %v, want %q", when, got, err, want)
+		}
+		if n, err := e.Len(); n != len(want) || err != nil {
+			t.Errorf("Len %s = %d, %v, want %d", when, n, err, len(want))
+		}
+		for _, member := range []string{"a", "b", "c"} {
+			if ok, _ := e.Has(member); ok != slices.Contains(want, member) {
+				t.Errorf("Has(%s) %s = %v", member, when, ok)
+			}
+		}
+	}
+
+	e.Insert("A")
+	e.InsertTTL("b", 2*time.Minute)
+	e.Insert("c")
+	check("after inserting", []string{"a", "c", "b"})
+
+	clock.advance(90 * time.Second)
+	check("after a and c expired", []string{"b"})
+	if n, err := e.Prune(); n != 2 || err != nil {
+		t.Errorf("Prune = %d, %v, want 2", n, err)
+	}
+	if got, _ := server.ZMembers("expiring"); !slices.Equal(got, []string{"b"}) {
+		t.Errorf("stored after Prune = %v, want [b]", got)
+	}
+
+	// Inserting again extends a member's life, even past its neighbours.
+	e.Insert("a")
+	check("after reinserting a", []string{"b", "a"})
+	e.InsertTTL("a", time.Second)
+	clock.advance(2 * time.Second)
+	check("after shortening a's life", []string{"b"})
+
+	if err := e.Remove("B"); err != nil {
+		t.Fatal(err)
+	}
+	check("after removing b", []string{})
+	if n, err := e.Prune(); n != 1 || err != nil {
+		t.Errorf("Prune = %d, %v, want the expired a", n, err)
+	}
+	if server.Exists("expiring") {
+		t.Error("key survived pruning its last member")
+	}
+}
+
+// TestExpiringSetOverflow checks that counts beyond 32 bits are returned
+// whole, or refused where int cannot hold them, never truncated.
+func TestExpiringSetOverflow(t *testing.T) {
+	client, _ := newTestClient(t)
+	client.AddHook(countHook{"zcount", 1 << 40})
+	client.AddHook(countHook{"zremrangebyscore", 1 << 40})
+	e, err := NewExpiring(client, "large", time.Minute, WithLogger(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, count := range map[string]func() (int, error){"Len": e.Len, "Prune": e.Prune} {
+		n, err := count()
+		if math.MaxInt > 1<<40 {
+			if int64(n) != 1<<40 || err != nil {
+				t.Errorf("%s = %d, %v, want %d", name, n, err, int64(1<<40))
+			}
+		} else if err == nil {
+			t.Errorf("%s = %d, want an overflow error", name, n)
+		}
+	}
+}
@@ -0,0 +1,206 @@
+package redisstringset
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// newTestClient returns a client connected to a fresh miniredis server, both
+// closed when the test ends. The client retries neither commands nor dials, so
+// failures surface at once.
+func newTestClient(t testing.TB) (*redis.Client, *miniredis.Miniredis) {
+	t.Helper()
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr(), MaxRetries: -1, DialerRetries: 1})
+	t.Cleanup(func() { client.Close() })
+	return client, server
+}
+
+// newTestSet returns a Set on key, logging nothing, backed by a fresh
+// miniredis server.
+func newTestSet(t testing.TB, key string, opts ...Option) (*Set, *miniredis.Miniredis) {
+	t.Helper()
+	client, server := newTestClient(t)
+	return newClientSet(t, client, key, opts...), server
+}
+
+// newDeadSet returns a Set on key whose miniredis server has been stopped, so
+// that every command fails with ErrUnavailable.
+func newDeadSet(t testing.TB, key string, opts ...Option) *Set {
+	t.Helper()
+	s, server := newTestSet(t, key, opts...)
+	server.Close()
+	return s
+}
+
+// newClientSet returns a Set on key using client, logging nothing.
+func newClientSet(t testing.TB, client redis.Cmdable, key string, opts ...Option) *Set {
+	t.Helper()
+	s, err := NewWithOptions(client, key, append([]Option{WithLogger(nil)}, opts...)...)
+	if err != nil {
+		t.Fatalf("NewWithOptions(%q): %v", key, err)
+	}
+	return s
+}
+
+// errRefused is the error of a command sent to a server refusing connections.
+var errRefused = &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}
+
+// replyError is an error reply from the server, such as WRONGTYPE.
+type replyError string
+
+func (e replyError) Error() string { return string(e) }
+
+func (replyError) RedisError() {}
+
+// faults is a go-redis hook failing chosen commands, turning a client into a
+// fake Cmdable that injects errors. Commands are named in lower case, as
+// "sadd" or "evalsha"; a pipeline fails as a whole if any of its commands is
+// chosen.
+type faults struct {
+	mu    sync.Mutex
+	rules map[string]*fault
+	calls map[string]int
+}
+
+type fault struct {
+	err error
+	// times is the number of failures left, or negative to fail forever.
+	times int
+	// then, if not nil, is called before each failure is returned, without
+	// the hook's lock held, so it may send commands itself.
+	then func()
+}
+
+// newFaultyClient returns a client connected to a fresh miniredis server and
+// the hook failing its commands.
+func newFaultyClient(t testing.TB) (*redis.Client, *faults, *miniredis.Miniredis) {
+	t.Helper()
+	client, server := newTestClient(t)
+	f := &faults{rules: make(map[string]*fault), calls: make(map[string]int)}
+	client.AddHook(f)
+	return client, f, server
+}
+
+// inject makes the next times commands named cmd fail with err, or all of
+// them if times is negative.
+func (f *faults) inject(cmd string, times int, err error) {
+	f.injectThen(cmd, times, err, nil)
+}
+
+// injectThen is like inject but also calls then before each failure.
+func (f *faults) injectThen(cmd string, times int, err error, then func()) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.rules[cmd] = &fault{err: err, times: times, then: then}
+}
+
+// heal clears every injected failure.
+func (f *faults) heal() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	clear(f.rules)
+}
+
+// count returns how many commands named cmd were sent or failed.
+func (f *faults) count(cmd string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls[cmd]
+}
+
+// check records cmd and returns the error it must fail with, if any.
+func (f *faults) check(cmd redis.Cmder) error {
+	then, err := f.match(cmd)
+	if then != nil {
+		then()
+	}
+	return err
+}
+
+func (f *faults) match(cmd redis.Cmder) (func(), error) {
+	name := strings.ToLower(cmd.Name())
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls[name]++
+	rule, ok := f.rules[name]
+	if !ok || rule.times == 0 {
+		return nil, nil
+	}
+	if rule.times > 0 {
+		rule.times--
+	}
+	return rule.then, rule.err
+}
+
+func (f *faults) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+func (f *faults) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		if err := f.check(cmd); err != nil {
+			cmd.SetErr(err)
+			return err
+		}
+		return next(ctx, cmd)
+	}
+}
+
+func (f *faults) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		var err error
+		for _, cmd := range cmds {
+			if cerr := f.check(cmd); cerr != nil && err == nil {
+				err = cerr
+			}
+		}
+		if err == nil {
+			return next(ctx, cmds)
+		}
+		for _, cmd := range cmds {
+			cmd.SetErr(err)
+		}
+		return err
+	}
+}
+
+// logRecorder is a Logger keeping every message it is given.
+type logRecorder struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+func (l *logRecorder) Printf(format string, v ...any) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.lines = append(l.lines, fmt.Sprintf(format, v...))
+}
+
+// messages returns the messages logged so far.
+func (l *logRecorder) messages() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return slices.Clone(l.lines)
+}
+
+// mustMembers returns the members stored under key on server, failing the
+// test if they cannot be read.
+func mustMembers(t testing.TB, server *miniredis.Miniredis, key string) []string {
+	t.Helper()
+	members, err := server.Members(key)
+	if err != nil && !errors.Is(err, miniredis.ErrKeyNotFound) {
+		t.Fatalf("reading %s: %v", key, err)
+	}
+	return members
+}
@@ -0,0 +1,114 @@
+package redisstringset
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// lineEscaper escapes backslashes and newlines so that every member occupies
+// exactly one line in WriteTo's output.
+var lineEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+
+// WriteTo writes every member of the Set to w, one per line, and returns the
+// number of bytes written, satisfying io.WriterTo. Members are fetched with
+// SSCAN as Each does, so the Set is never held in memory as a whole.
+// Backslashes and newlines within members are escaped as \\ and \n; ReadFrom
+// reverses the escaping.
+func (s *Set) WriteTo(w io.Writer) (int64, error) {
+	return s.WriteToCtx(context.Background(), w)
+}
+
+// WriteToCtx is like WriteTo but uses ctx for every Redis command.
+func (s *Set) WriteToCtx(ctx context.Context, w io.Writer) (int64, error) {
+	bw := bufio.NewWriter(w)
+	var written int64
+	err := s.Each(ctx, func(member string) error {
+		n, err := lineEscaper.WriteString(bw, member)
+		written += int64(n)
+		if err == nil {
+			err = bw.WriteByte('\n')
+			if err == nil {
+				written++
+			}
+		}
+		return err
+	})
+	if ferr := bw.Flush(); err == nil {
+		err = ferr
+	}
+	return written - int64(bw.Buffered()), err
+}
+
+// ReadFrom inserts the lines read from r until EOF, in the format written by
+// WriteTo, and returns the number of bytes read, satisfying io.ReaderFrom.
+// Each line is unescaped and then normalized like an Insert argument. Lines
+// are inserted in batches as InsertMany does, so a failure may leave earlier
+// batches inserted.
+func (s *Set) ReadFrom(r io.Reader) (int64, error) {
+	return s.ReadFromCtx(context.Background(), r)
+}
+
+// ReadFromCtx is like ReadFrom but uses ctx for every Redis command.
+func (s *Set) ReadFromCtx(ctx context.Context, r io.Reader) (int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	br := bufio.NewReader(r)
+	members := make([]string, 0, s.batchSize)
+	var read int64
+	for {
+		line, err := br.ReadString('\n')
+		read += int64(len(line))
+		if err != nil && !errors.Is(err, io.EOF) {
+			return read, s.fail(fmt.Errorf("reading members for %s: %w", s.key, err))
+		}
+		if line != "" {
+			member, ok, ierr := s.insertable(unescapeLine(strings.TrimSuffix(line, "\n")))
+			if ierr != nil {
+				return read, ierr
+			}
+			if ok {
+				members = append(members, member)
+			}
+		}
+		if len(members) == s.batchSize || err != nil {
+			if _, aerr := s.addMembers(ctx, members); aerr != nil {
+				return read, aerr
+			}
+			members = members[:0]
+		}
+		if err != nil {
+			return read, nil
+		}
+	}
+}
+
+// unescapeLine reverses lineEscaper. Unknown escapes are kept as is.
+func unescapeLine(line string) string {
+	if !strings.Contains(line, `\`) {
+		return line
+	}
+	var b strings.Builder
+	b.Grow(len(line))
+	for i := 0; i < len(line); i++ {
+		if line[i] != '\\' || i+1 == len(line) {
+			b.WriteByte(line[i])
+			continue
+		}
+		i++
+		switch line[i] {
+		case 'n':
+			b.WriteByte('\n')
+		case '\\':
+			b.WriteByte('\\')
+		default:
+			b.WriteByte('\\')
+			b.WriteByte(line[i])
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,54 @@
+package redisstringset
+
+import (
+	"bytes"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// errWriter fails every write.
+type errWriter struct{ err error }
+
+func (w errWriter) Write([]byte) (int, error) { return 0, w.err }
+
+func TestWriteToReadFrom(t *testing.T) {
+	members := []string{"", "Plain", "two\nlines", `back\slash`, `\n`, `trailing\`, "\\\n\\"}
+	src, _ := newTestSet(t, "src", CaseSensitive())
+	if err := src.InsertMany(members...); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	n, err := src.WriteTo(&buf)
+	if err != nil || n != int64(buf.Len()) {
+		t.Fatalf("WriteTo = %d, %v, want %d bytes", n, err, buf.Len())
+	}
+	if lines := strings.Count(buf.String(), "\n"); lines != len(members) {
+		t.Errorf("WriteTo wrote %d lines, want one per member:\n%s", lines, buf.String())
+	}
+
+	dst, server := newTestSet(t, "dst", CaseSensitive())
+	written := int64(buf.Len())
+	if n, err := dst.ReadFrom(&buf); n != written || err != nil {
+		t.Fatalf("ReadFrom = %d, %v, want %d bytes", n, err, written)
+	}
+	want := slices.Sorted(slices.Values(members))
+	if got := mustMembers(t, server, "dst"); !slices.Equal(got, want) {
+		t.Errorf("round trip = %q, want %q", got, want)
+	}
+
+	// A last line without a newline is still read, and lines are normalized.
+	lower, server := newTestSet(t, "lower")
+	if _, err := lower.ReadFrom(strings.NewReader("A\nb")); err != nil {
+		t.Fatal(err)
+	}
+	if got := mustMembers(t, server, "lower"); !slices.Equal(got, []string{"a", "b"}) {
+		t.Errorf("ReadFrom = %v, want [a b]", got)
+	}
+
+	errBroken := errors.New("broken")
+	if _, err := src.WriteTo(errWriter{errBroken}); !errors.Is(err, errBroken) {
+		t.Errorf("WriteTo a failing writer = %v, want its error", err)
+	}
+}
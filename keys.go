@@ -0,0 +1,229 @@
+package redisstringset
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// copyScript emulates COPY with SUNIONSTORE for servers older than Redis 6.2.
+// ARGV[1] is "1" to replace an existing destination.
+var copyScript = redis.NewScript(`
+if ARGV[1] ~= "1" and redis.call("EXISTS", KEYS[2]) == 1 then
+	return 0
+end
+redis.call("SUNIONSTORE", KEYS[2], KEYS[1])
+return 1
+`)
+
+// swapScript exchanges the contents of KEYS[1] and KEYS[2] through the
+// temporary KEYS[3]. Missing keys stand for empty Sets. ARGV[1] and ARGV[2]
+// are the idle TTLs in milliseconds of KEYS[1] and KEYS[2], "0" for none.
+var swapScript = redis.NewScript(`
+local a = redis.call("EXISTS", KEYS[1]) == 1
+local b = redis.call("EXISTS", KEYS[2]) == 1
+if a then redis.call("RENAME", KEYS[1], KEYS[3]) end
+if b then redis.call("RENAME", KEYS[2], KEYS[1]) end
+if a then redis.call("RENAME", KEYS[3], KEYS[2]) end
+for i = 1, 2 do
+	if tonumber(ARGV[i]) > 0 then redis.call("PEXPIRE", KEYS[i], ARGV[i]) end
+end
+return 1
+`)
+
+// CopyTo copies the receiver's members to newKey and returns a Set bound to
+// newKey with the receiver's client and configuration; the receiver is not
+// modified. If newKey already exists, CopyTo fails with ErrKeyExists unless
+// replace is true, in which case its contents are overwritten. CopyTo uses the
+// COPY command, falling back to SUNIONSTORE in a script on servers older than
+// Redis 6.2.
+func (s *Set) CopyTo(newKey string, replace bool) (*Set, error) {
+	return s.CopyToCtx(context.Background(), newKey, replace)
+}
+
+// CopyToCtx is like CopyTo but uses ctx for every Redis command.
+func (s *Set) CopyToCtx(ctx context.Context, newKey string, replace bool) (*Set, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	newKey = s.tagged(newKey)
+	if err := s.checkSlots(s.key, newKey); err != nil {
+		return nil, s.fail(fmt.Errorf("copying %s to %s: %w", s.key, newKey, err))
+	}
+
+	var copied bool
+	var err error
+	if s.noCopy.Load() {
+		copied, err = s.copyScripted(ctx, newKey, replace)
+	} else {
+		copied, err = s.copyKey(ctx, newKey, replace)
+		if isUnknownCommand(err) {
+			s.noCopy.Store(true)
+			copied, err = s.copyScripted(ctx, newKey, replace)
+		}
+	}
+	if err != nil {
+		s.logger.Printf("Error copying %s to %s: %v", s.key, newKey, err)
+		return nil, s.fail(fmt.Errorf("copying %s to %s: %w", s.key, newKey, classify(err)))
+	}
+	if !copied {
+		return nil, s.fail(fmt.Errorf("copying %s to %s: %w", s.key, newKey, ErrKeyExists))
+	}
+	return s.derive(newKey), nil
+}
+
+// copyKey issues COPY. It reports false only when newKey exists and replace
+// is false. The caller must hold the lock.
+func (s *Set) copyKey(ctx context.Context, newKey string, replace bool) (bool, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	args := []interface{}{"COPY", s.key, newKey}
+	if replace {
+		args = append(args, "REPLACE")
+	}
+	n, err := s.do(ctx, args...).Int64()
+	if err != nil || n == 1 {
+		return n == 1, err
+	}
+
+	// COPY also replies 0 when the source does not exist, which for a Set
+	// means it is empty. Tell the two cases apart and mirror the empty Set.
+	sourceExists, err := s.redisClient.Exists(ctx, s.key).Result()
+	if err != nil || sourceExists == 1 {
+		return false, err
+	}
+	if replace {
+		return true, s.redisClient.Del(ctx, newKey).Err()
+	}
+	destExists, err := s.redisClient.Exists(ctx, newKey).Result()
+	return destExists == 0, err
+}
+
+// copyScripted is copyKey for servers without COPY. The caller must hold the
+// lock.
+func (s *Set) copyScripted(ctx context.Context, newKey string, replace bool) (bool, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	flag := "0"
+	if replace {
+		flag = "1"
+	}
+	n, err := copyScript.Run(ctx, s.redisClient, []string{s.key, newKey}, flag).Int64()
+	return n == 1, err
+}
+
+// Rename renames the receiver's key to newKey with RENAME, replacing anything
+// stored there, and binds the Set to newKey so that later operations use it.
+// Renaming an empty Set, whose key does not exist, deletes newKey instead.
+// Operations on the Set wait for a rename in progress.
+func (s *Set) Rename(newKey string) error {
+	return s.RenameCtx(context.Background(), newKey)
+}
+
+// RenameCtx is like Rename but uses ctx for the Redis command.
+func (s *Set) RenameCtx(ctx context.Context, newKey string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	defer s.cache.flush()
+	newKey = s.tagged(newKey)
+	if err := s.checkSlots(s.key, newKey); err != nil {
+		return s.fail(fmt.Errorf("renaming %s to %s: %w", s.key, newKey, err))
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	err := s.redisClient.Rename(ctx, s.key, newKey).Err()
+	if hasReplyPrefix(err, "ERR") && strings.Contains(err.Error(), "no such key") {
+		err = s.redisClient.Del(ctx, newKey).Err()
+	}
+	if err != nil {
+		s.logger.Printf("Error renaming %s to %s: %v", s.key, newKey, err)
+		return s.fail(fmt.Errorf("renaming %s to %s: %w", s.key, newKey, classify(err)))
+	}
+	s.key = newKey
+	return nil
+}
+
+// RenameNX is like Rename but uses RENAMENX, leaving both keys and the Set
+// unchanged and reporting false if newKey already exists. An empty Set is
+// rebound to newKey only if newKey does not exist.
+func (s *Set) RenameNX(newKey string) (bool, error) {
+	return s.RenameNXCtx(context.Background(), newKey)
+}
+
+// RenameNXCtx is like RenameNX but uses ctx for the Redis command.
+func (s *Set) RenameNXCtx(ctx context.Context, newKey string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	defer s.cache.flush()
+	newKey = s.tagged(newKey)
+	if err := s.checkSlots(s.key, newKey); err != nil {
+		return false, s.fail(fmt.Errorf("renaming %s to %s: %w", s.key, newKey, err))
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	renamed, err := s.redisClient.RenameNX(ctx, s.key, newKey).Result()
+	if hasReplyPrefix(err, "ERR") && strings.Contains(err.Error(), "no such key") {
+		var exists int64
+		exists, err = s.redisClient.Exists(ctx, newKey).Result()
+		renamed = exists == 0
+	}
+	if err != nil {
+		s.logger.Printf("Error renaming %s to %s: %v", s.key, newKey, err)
+		return false, s.fail(fmt.Errorf("renaming %s to %s: %w", s.key, newKey, classify(err)))
+	}
+	if renamed {
+		s.key = newKey
+	}
+	return renamed, nil
+}
+
+// Swap atomically exchanges the contents of the receiver and the other Set, so
+// that readers observe either the old or the new contents of each, never a
+// partial state. Both Sets stay bound to their own keys; only the data moves.
+// The exchange runs as a script of RENAMEs through a temporary key, unique to
+// the call and in the receiver's cluster slot, which never outlives the
+// script. Swap fails with ErrDifferentClients if other uses another client.
+func (s *Set) Swap(other *Set) error {
+	return s.SwapCtx(context.Background(), other)
+}
+
+// SwapCtx is like Swap but uses ctx for the Redis command.
+func (s *Set) SwapCtx(ctx context.Context, other *Set) error {
+	if err := checkSets([]*Set{other}); err != nil {
+		return s.fail(fmt.Errorf("swapping %s: %w", s.key, err))
+	}
+	otherKey := other.lockedKey()
+	if !s.sharesClient(other) {
+		return s.fail(fmt.Errorf("swapping %s and %s: %w", s.key, otherKey, ErrDifferentClients))
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if otherKey == s.key {
+		return nil
+	}
+	token, err := randomToken()
+	if err != nil {
+		return s.fail(fmt.Errorf("swapping %s and %s: generating temporary key: %w", s.key, otherKey, err))
+	}
+	tempKey := sibling(s.key, ":swap:"+token)
+	if err := s.checkSlots(s.key, otherKey, tempKey); err != nil {
+		return s.fail(fmt.Errorf("swapping %s and %s: %w", s.key, otherKey, err))
+	}
+	defer s.cache.flush()
+	defer other.cache.flush()
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	if err := swapScript.Run(ctx, s.redisClient, []string{s.key, otherKey, tempKey},
+		max(s.idleTTL.Milliseconds(), 0), max(other.idleTTL.Milliseconds(), 0)).Err(); err != nil {
+		s.logger.Printf("Error swapping %s and %s: %v", s.key, otherKey, err)
+		return s.fail(fmt.Errorf("swapping %s and %s: %w", s.key, otherKey, classify(err)))
+	}
+	return nil
+}
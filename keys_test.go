@@ -0,0 +1,196 @@
+package redisstringset
+
+import (
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+)
+
+func TestSwap(t *testing.T) {
+	client, server := newTestClient(t)
+	live := newClientSet(t, client, "live")
+	staging := newClientSet(t, client, "staging")
+	live.InsertMany("old1", "old2")
+	staging.InsertMany("new1", "new2", "new3")
+	server.SAdd("live:swap", "bystander")
+
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for _, s := range []*Set{live, staging} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				if n, err := s.Len(); err != nil || n == 0 {
+					t.Errorf("Len of %s during swaps = %d, %v", s.Key(), n, err)
+					return
+				}
+			}
+		}()
+	}
+	for i := 0; i < 51; i++ {
+		if err := live.Swap(staging); err != nil {
+			t.Fatalf("Swap: %v", err)
+		}
+	}
+	close(stop)
+	wg.Wait()
+
+	if got, want := mustMembers(t, server, "live"), []string{"new1", "new2", "new3"}; !slices.Equal(got, want) {
+		t.Errorf("live = %v, want %v", got, want)
+	}
+	if got, want := mustMembers(t, server, "staging"), []string{"old1", "old2"}; !slices.Equal(got, want) {
+		t.Errorf("staging = %v, want %v", got, want)
+	}
+	if got, want := server.Keys(), []string{"live", "live:swap", "staging"}; !slices.Equal(got, want) {
+		t.Errorf("keys after swapping = %v, want %v", got, want)
+	}
+}
+
+func TestSibling(t *testing.T) {
+	for _, key := range []string{"plain", "{tag}:key", "pre{tag}post", "{}empty", "open{only", "close}only"} {
+		got := sibling(key, ":swap:1")
+		if key == "{}empty" || key == "close}only" {
+			// A "}" outside any hash tag cannot be wrapped in braces.
+			if got != key+":swap:1" {
+				t.Errorf("sibling(%q) = %q, want the suffix appended", key, got)
+			}
+			continue
+		}
+		if keySlot(got) != keySlot(key) {
+			t.Errorf("sibling(%q) = %q in slot %d, want slot %d", key, got, keySlot(got), keySlot(key))
+		}
+	}
+}
+
+func TestCopyTo(t *testing.T) {
+	for _, scripted := range []bool{false, true} {
+		name := "COPY"
+		if scripted {
+			name = "script"
+		}
+		t.Run(name, func(t *testing.T) {
+			client, f, server := newFaultyClient(t)
+			if scripted {
+				f.inject("copy", -1, errUnknownCommand("copy"))
+			}
+			s := newClientSet(t, client, "source")
+			s.InsertMany("a", "b")
+			server.SAdd("taken", "x")
+
+			snapshot, err := s.CopyTo("snapshot", false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if snapshot.Key() != "snapshot" {
+				t.Errorf("copy bound to %q, want snapshot", snapshot.Key())
+			}
+			if got, _ := snapshot.Slice(); len(got) != 2 {
+				t.Errorf("copy = %v, want [a b]", got)
+			}
+			if _, err := s.CopyTo("taken", false); !errors.Is(err, ErrKeyExists) {
+				t.Errorf("CopyTo an existing key = %v, want ErrKeyExists", err)
+			}
+			if got := mustMembers(t, server, "taken"); !slices.Equal(got, []string{"x"}) {
+				t.Errorf("taken = %v, want it untouched without replace", got)
+			}
+			if _, err := s.CopyTo("taken", true); err != nil {
+				t.Fatalf("CopyTo with replace = %v", err)
+			}
+			if n := f.count("copy"); scripted && n != 1 {
+				t.Errorf("sent COPY %d times, want it given up after the first", n)
+			}
+			if got := mustMembers(t, server, "taken"); !slices.Equal(got, []string{"a", "b"}) {
+				t.Errorf("taken = %v, want it replaced by [a b]", got)
+			}
+
+			// The copy is independent of the source.
+			snapshot.Insert("c")
+			if got := mustMembers(t, server, "source"); !slices.Equal(got, []string{"a", "b"}) {
+				t.Errorf("source = %v, want it untouched", got)
+			}
+
+			// Redis deletes the destination of an empty SUNIONSTORE, where
+			// miniredis leaves an empty key, so only the members are checked.
+			empty := newClientSet(t, client, "empty")
+			if _, err := empty.CopyTo("taken", true); err != nil || len(mustMembers(t, server, "taken")) != 0 {
+				t.Errorf("CopyTo of an empty Set with replace = %v, want taken emptied", err)
+			}
+			if _, err := empty.CopyTo("snapshot", false); !errors.Is(err, ErrKeyExists) {
+				t.Errorf("CopyTo of an empty Set onto an existing key = %v, want ErrKeyExists", err)
+			}
+			if _, err := empty.CopyTo("fresh", false); err != nil || len(mustMembers(t, server, "fresh")) != 0 {
+				t.Errorf("CopyTo of an empty Set to a fresh key = %v", err)
+			}
+		})
+	}
+}
+
+func TestRename(t *testing.T) {
+	s, server := newTestSet(t, "staging")
+	s.InsertMany("a", "b")
+	server.SAdd("live", "old")
+	if err := s.Rename("live"); err != nil {
+		t.Fatal(err)
+	}
+	if server.Exists("staging") {
+		t.Error("staging still exists after Rename")
+	}
+	if s.Key() != "live" {
+		t.Errorf("Key = %q after Rename, want live", s.Key())
+	}
+	s.Insert("c")
+	if got := mustMembers(t, server, "live"); !slices.Equal(got, []string{"a", "b", "c"}) {
+		t.Errorf("live = %v, want the renamed members and later inserts", got)
+	}
+
+	empty, server := newTestSet(t, "empty")
+	server.SAdd("target", "x")
+	if err := empty.Rename("target"); err != nil {
+		t.Fatalf("Rename of an empty Set = %v", err)
+	}
+	if server.Exists("target") || empty.Key() != "target" {
+		t.Errorf("Rename of an empty Set left target and bound to %q, want target deleted and bound", empty.Key())
+	}
+}
+
+func TestRenameNX(t *testing.T) {
+	s, server := newTestSet(t, "staging")
+	s.InsertMany("a", "b")
+	server.SAdd("live", "old")
+	if renamed, err := s.RenameNX("live"); renamed || err != nil {
+		t.Errorf("RenameNX onto an existing key = %v, %v, want false", renamed, err)
+	}
+	if s.Key() != "staging" {
+		t.Errorf("Key = %q after a refused RenameNX, want staging", s.Key())
+	}
+	if got := mustMembers(t, server, "live"); !slices.Equal(got, []string{"old"}) {
+		t.Errorf("live = %v, want it untouched", got)
+	}
+	if renamed, err := s.RenameNX("promoted"); !renamed || err != nil {
+		t.Fatalf("RenameNX to a free key = %v, %v, want true", renamed, err)
+	}
+	if server.Exists("staging") || s.Key() != "promoted" {
+		t.Errorf("after RenameNX staging exists = %v, Key = %q", server.Exists("staging"), s.Key())
+	}
+	if ok, _ := s.Has("a"); !ok {
+		t.Error("Has(a) = false after RenameNX")
+	}
+
+	empty := newClientSet(t, s.Client(), "empty")
+	if renamed, err := empty.RenameNX("live"); renamed || err != nil || empty.Key() != "empty" {
+		t.Errorf("RenameNX of an empty Set onto an existing key = %v, %v, bound to %q", renamed, err, empty.Key())
+	}
+	if renamed, err := empty.RenameNX("fresh"); !renamed || err != nil || empty.Key() != "fresh" {
+		t.Errorf("RenameNX of an empty Set to a free key = %v, %v, bound to %q", renamed, err, empty.Key())
+	}
+	if server.Exists("fresh") {
+		t.Error("RenameNX of an empty Set created its key")
+	}
+}
@@ -0,0 +1,446 @@
+package redisstringset
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// LocalSet is an in-memory set of strings with the same normalization and
+// method signatures as Set, for tests and single-process deployments that do
+// not need Redis. It satisfies StringSet and is safe for concurrent use. Its
+// methods return errors for parity with Set, but only RejectEmpty ever
+// produces one.
+//
+// Like Set, operations involving two LocalSets never hold both locks at once:
+// the other set is read first and the receiver is locked afterwards.
+type LocalSet struct {
+	mu      sync.RWMutex
+	members map[string]nothing
+	normalization
+}
+
+var _ StringSet = (*LocalSet)(nil)
+
+// NewLocal returns a LocalSet containing the initial values, normalized as a
+// Set with default options would normalize them.
+func NewLocal(initial ...string) *LocalSet {
+	l := NewLocalWithOptions()
+	l.InsertMany(initial...)
+	return l
+}
+
+// NewLocalWithOptions returns an empty LocalSet configured by opts. Only the
+// options affecting normalization apply: CaseSensitive, WithNormalizer, the
+// case folding options, WithTrimSpace and RejectEmpty. The others are ignored.
+func NewLocalWithOptions(opts ...Option) *LocalSet {
+	return &LocalSet{
+		normalization: normalizationOf(opts),
+		members:       make(map[string]nothing),
+	}
+}
+
+// DeduplicateLocal is the in-memory counterpart of Deduplicate: it returns
+// the normalized members of input, each once, in the order of their first
+// occurrence. opts are interpreted as by NewLocalWithOptions.
+func DeduplicateLocal(input []string, opts ...Option) ([]string, error) {
+	l := NewLocalWithOptions(opts...)
+	result := []string{}
+	for _, element := range input {
+		member, ok, err := l.insertable(element)
+		if err != nil {
+			return nil, err
+		}
+		if _, seen := l.members[member]; ok && !seen {
+			l.members[member] = nothing{}
+			result = append(result, member)
+		}
+	}
+	return result, nil
+}
+
+// Insert adds element to the LocalSet.
+func (l *LocalSet) Insert(element string) error {
+	return l.InsertMany(element)
+}
+
+// InsertMany adds all the elements to the LocalSet. With RejectEmpty, an
+// empty element fails the whole call before anything is inserted.
+func (l *LocalSet) InsertMany(elements ...string) error {
+	members := make([]string, 0, len(elements))
+	for _, element := range elements {
+		member, ok, err := l.insertable(element)
+		if err != nil {
+			return err
+		}
+		if ok {
+			members = append(members, member)
+		}
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, member := range members {
+		l.members[member] = nothing{}
+	}
+	return nil
+}
+
+// Remove deletes element from the LocalSet.
+func (l *LocalSet) Remove(element string) error {
+	_, err := l.RemoveMany(element)
+	return err
+}
+
+// RemoveMany deletes all the elements from the LocalSet and returns how many
+// of them were members.
+func (l *LocalSet) RemoveMany(elements ...string) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	removed := 0
+	for _, element := range elements {
+		member, ok := l.normalize(element)
+		if _, present := l.members[member]; ok && present {
+			delete(l.members, member)
+			removed++
+		}
+	}
+	return removed, nil
+}
+
+// Has reports whether element is a member of the LocalSet.
+func (l *LocalSet) Has(element string) (bool, error) {
+	member, ok := l.normalize(element)
+	if !ok {
+		return false, nil
+	}
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	_, present := l.members[member]
+	return present, nil
+}
+
+// HasAll reports whether every element is a member of the LocalSet. It
+// reports true when called without elements.
+func (l *LocalSet) HasAll(elements ...string) (bool, error) {
+	for _, element := range elements {
+		if present, _ := l.Has(element); !present {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// HasAny reports whether at least one element is a member of the LocalSet.
+func (l *LocalSet) HasAny(elements ...string) (bool, error) {
+	for _, element := range elements {
+		if present, _ := l.Has(element); present {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Slice returns the members of the LocalSet in no particular order. An empty
+// LocalSet yields a non-nil empty slice.
+func (l *LocalSet) Slice() ([]string, error) {
+	return l.snapshot(), nil
+}
+
+// SliceSorted is like Slice but returns the members in lexicographic order,
+// or in reverse order if descending is true.
+func (l *LocalSet) SliceSorted(descending bool) ([]string, error) {
+	members := l.snapshot()
+	slices.Sort(members)
+	if descending {
+		slices.Reverse(members)
+	}
+	return members, nil
+}
+
+// ToMap returns the members of the LocalSet as the keys of a new map.
+func (l *LocalSet) ToMap() (map[string]struct{}, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	result := make(map[string]struct{}, len(l.members))
+	for member := range l.members {
+		result[member] = struct{}{}
+	}
+	return result, nil
+}
+
+// Len returns the number of members in the LocalSet.
+func (l *LocalSet) Len() (int, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return len(l.members), nil
+}
+
+// IsEmpty reports whether the LocalSet has no members.
+func (l *LocalSet) IsEmpty() (bool, error) {
+	n, err := l.Len()
+	return n == 0, err
+}
+
+// Clear removes every member of the LocalSet.
+func (l *LocalSet) Clear() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	clear(l.members)
+	return nil
+}
+
+// Close does nothing; it exists so that LocalSet satisfies StringSet. Like a
+// Set's, the LocalSet's methods keep working after it.
+func (l *LocalSet) Close() error {
+	return nil
+}
+
+// Union adds the members of other to the receiver.
+func (l *LocalSet) Union(other *LocalSet) error {
+	return l.UnionAll(other)
+}
+
+// UnionAll adds the members of every one of others to the receiver.
+func (l *LocalSet) UnionAll(others ...*LocalSet) error {
+	snapshots, err := snapshotsOf(others)
+	if err != nil {
+		return fmt.Errorf("computing union into local set: %w", err)
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, members := range snapshots {
+		for _, member := range members {
+			l.members[member] = nothing{}
+		}
+	}
+	return nil
+}
+
+// Intersect removes from the receiver every member not in other.
+func (l *LocalSet) Intersect(other *LocalSet) error {
+	return l.IntersectAll(other)
+}
+
+// IntersectAll removes from the receiver every member missing from any of
+// others.
+func (l *LocalSet) IntersectAll(others ...*LocalSet) error {
+	sets, err := setsOf(others)
+	if err != nil {
+		return fmt.Errorf("computing intersection into local set: %w", err)
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for member := range l.members {
+		if !inAll(sets, member) {
+			delete(l.members, member)
+		}
+	}
+	return nil
+}
+
+// Subtract removes the members of other from the receiver.
+func (l *LocalSet) Subtract(other *LocalSet) error {
+	return l.SubtractAll(other)
+}
+
+// SubtractAll removes the members of every one of others from the receiver.
+func (l *LocalSet) SubtractAll(others ...*LocalSet) error {
+	snapshots, err := snapshotsOf(others)
+	if err != nil {
+		return fmt.Errorf("computing difference into local set: %w", err)
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, members := range snapshots {
+		for _, member := range members {
+			delete(l.members, member)
+		}
+	}
+	return nil
+}
+
+// UnionSlice returns the members of the receiver or other without modifying
+// either.
+func (l *LocalSet) UnionSlice(other *LocalSet) ([]string, error) {
+	if other == nil {
+		return nil, fmt.Errorf("computing union of local set: %w", ErrNilSet)
+	}
+	theirs := other.snapshot()
+	merged := l.set()
+	result := make([]string, 0, len(merged)+len(theirs))
+	for member := range merged {
+		result = append(result, member)
+	}
+	for _, member := range theirs {
+		if _, ok := merged[member]; !ok {
+			result = append(result, member)
+		}
+	}
+	return result, nil
+}
+
+// IntersectSlice returns the members of the receiver found in every one of
+// others without modifying any of them.
+func (l *LocalSet) IntersectSlice(others ...*LocalSet) ([]string, error) {
+	sets, err := setsOf(others)
+	if err != nil {
+		return nil, fmt.Errorf("computing intersection of local set: %w", err)
+	}
+	result := []string{}
+	for _, member := range l.snapshot() {
+		if inAll(sets, member) {
+			result = append(result, member)
+		}
+	}
+	return result, nil
+}
+
+// DiffSlice returns the members of the receiver not in other without
+// modifying either.
+func (l *LocalSet) DiffSlice(other *LocalSet) ([]string, error) {
+	if other == nil {
+		return nil, fmt.Errorf("computing difference of local set: %w", ErrNilSet)
+	}
+	return difference(l.snapshot(), other.set()), nil
+}
+
+// SymmetricDifference returns the members in exactly one of the receiver and
+// other without modifying either.
+func (l *LocalSet) SymmetricDifference(other *LocalSet) ([]string, error) {
+	if other == nil {
+		return nil, fmt.Errorf("computing symmetric difference of local set: %w", ErrNilSet)
+	}
+	mine, theirs := l.set(), other.set()
+	result := difference(l.snapshot(), theirs)
+	return append(result, difference(other.snapshot(), mine)...), nil
+}
+
+// Equal reports whether the receiver and other have the same members.
+func (l *LocalSet) Equal(other *LocalSet) (bool, error) {
+	if other == nil {
+		return false, fmt.Errorf("comparing local set: %w", ErrNilSet)
+	}
+	theirs := other.set()
+	mine := l.set()
+	if len(mine) != len(theirs) {
+		return false, nil
+	}
+	return isSubsetOf(mine, theirs), nil
+}
+
+// IsSubsetOf reports whether every member of the receiver is in other.
+func (l *LocalSet) IsSubsetOf(other *LocalSet) (bool, error) {
+	if other == nil {
+		return false, fmt.Errorf("comparing local set: %w", ErrNilSet)
+	}
+	theirs := other.set()
+	return isSubsetOf(l.set(), theirs), nil
+}
+
+// IsSupersetOf reports whether every member of other is in the receiver.
+func (l *LocalSet) IsSupersetOf(other *LocalSet) (bool, error) {
+	if other == nil {
+		return false, fmt.Errorf("comparing local set: %w", ErrNilSet)
+	}
+	return other.IsSubsetOf(l)
+}
+
+// IsDisjointWith reports whether the receiver and other have no member in
+// common.
+func (l *LocalSet) IsDisjointWith(other *LocalSet) (bool, error) {
+	if other == nil {
+		return false, fmt.Errorf("comparing local set: %w", ErrNilSet)
+	}
+	theirs := other.set()
+	for member := range l.set() {
+		if _, ok := theirs[member]; ok {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// insertable normalizes element for insertion, failing with ErrEmptyElement
+// if the LocalSet rejects empty elements.
+func (l *LocalSet) insertable(element string) (string, bool, error) {
+	member, ok := l.normalize(element)
+	if !ok && l.rejectEmpty {
+		return "", false, fmt.Errorf("inserting into local set: %w", ErrEmptyElement)
+	}
+	return member, ok, nil
+}
+
+// snapshot returns a copy of the members as a slice.
+func (l *LocalSet) snapshot() []string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	result := make([]string, 0, len(l.members))
+	for member := range l.members {
+		result = append(result, member)
+	}
+	return result
+}
+
+// set returns a copy of the members as a map.
+func (l *LocalSet) set() map[string]nothing {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	result := make(map[string]nothing, len(l.members))
+	for member := range l.members {
+		result[member] = nothing{}
+	}
+	return result
+}
+
+// snapshotsOf returns a copy of the members of each of sets, failing with
+// ErrNilSet if one of them is nil.
+func snapshotsOf(sets []*LocalSet) ([][]string, error) {
+	result := make([][]string, 0, len(sets))
+	for _, l := range sets {
+		if l == nil {
+			return nil, ErrNilSet
+		}
+		result = append(result, l.snapshot())
+	}
+	return result, nil
+}
+
+// setsOf is like snapshotsOf but copies the members as maps.
+func setsOf(sets []*LocalSet) ([]map[string]nothing, error) {
+	result := make([]map[string]nothing, 0, len(sets))
+	for _, l := range sets {
+		if l == nil {
+			return nil, ErrNilSet
+		}
+		result = append(result, l.set())
+	}
+	return result, nil
+}
+
+func inAll(sets []map[string]nothing, member string) bool {
+	for _, set := range sets {
+		if _, ok := set[member]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func difference(members []string, exclude map[string]nothing) []string {
+	result := []string{}
+	for _, member := range members {
+		if _, ok := exclude[member]; !ok {
+			result = append(result, member)
+		}
+	}
+	return result
+}
+
+func isSubsetOf(sub, super map[string]nothing) bool {
+	for member := range sub {
+		if _, ok := super[member]; !ok {
+			return false
+		}
+	}
+	return true
+}
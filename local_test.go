@@ -0,0 +1,224 @@
+package redisstringset
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// localMembers returns the members of l in lexicographic order.
+func localMembers(t *testing.T, l *LocalSet) []string {
+	t.Helper()
+	members, err := l.SliceSorted(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return members
+}
+
+func TestLocalSetAlgebra(t *testing.T) {
+	tests := []struct {
+		name string
+		run  func(l, a, b *LocalSet) error
+		want []string
+	}{
+		{"Union", func(l, a, _ *LocalSet) error { return l.Union(a) }, []string{"a", "b", "c"}},
+		{"UnionAll", func(l, a, b *LocalSet) error { return l.UnionAll(a, b) }, []string{"a", "b", "c", "d"}},
+		{"Intersect", func(l, a, _ *LocalSet) error { return l.Intersect(a) }, []string{"b"}},
+		{"IntersectAll", func(l, a, b *LocalSet) error { return l.IntersectAll(a, b) }, []string{}},
+		{"Subtract", func(l, a, _ *LocalSet) error { return l.Subtract(a) }, []string{"a"}},
+		{"SubtractAll", func(l, a, b *LocalSet) error { return l.SubtractAll(a, b) }, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, a, b := NewLocal("a", "b"), NewLocal("b", "c"), NewLocal("a", "d")
+			if err := tt.run(l, a, b); err != nil {
+				t.Fatal(err)
+			}
+			if got := localMembers(t, l); !slices.Equal(got, tt.want) {
+				t.Errorf("members = %q, want %q", got, tt.want)
+			}
+			if got := localMembers(t, a); !slices.Equal(got, []string{"b", "c"}) {
+				t.Errorf("other modified to %q", got)
+			}
+			if err := tt.run(l, nil, NewLocal()); !errors.Is(err, ErrNilSet) {
+				t.Errorf("with a nil set: %v, want ErrNilSet", err)
+			}
+		})
+	}
+}
+
+func TestLocalSetSlices(t *testing.T) {
+	l, other := NewLocal("a", "b", "c"), NewLocal("b", "c", "d")
+	for _, tt := range []struct {
+		name string
+		run  func() ([]string, error)
+		want []string
+	}{
+		{"UnionSlice", func() ([]string, error) { return l.UnionSlice(other) }, []string{"a", "b", "c", "d"}},
+		{"IntersectSlice", func() ([]string, error) { return l.IntersectSlice(other) }, []string{"b", "c"}},
+		{"IntersectSlice of none", func() ([]string, error) { return l.IntersectSlice() }, []string{"a", "b", "c"}},
+		{"DiffSlice", func() ([]string, error) { return l.DiffSlice(other) }, []string{"a"}},
+		{"SymmetricDifference", func() ([]string, error) { return l.SymmetricDifference(other) }, []string{"a", "d"}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.run()
+			slices.Sort(got)
+			if !slices.Equal(got, tt.want) || err != nil {
+				t.Errorf("got %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+	if got := localMembers(t, l); !slices.Equal(got, []string{"a", "b", "c"}) {
+		t.Errorf("receiver modified to %q", got)
+	}
+
+	if got, err := l.UnionSlice(nil); got != nil || !errors.Is(err, ErrNilSet) {
+		t.Errorf("UnionSlice(nil) = %q, %v, want ErrNilSet", got, err)
+	}
+	if got, err := l.IntersectSlice(other, nil); got != nil || !errors.Is(err, ErrNilSet) {
+		t.Errorf("IntersectSlice(other, nil) = %q, %v, want ErrNilSet", got, err)
+	}
+	if got, err := l.DiffSlice(nil); got != nil || !errors.Is(err, ErrNilSet) {
+		t.Errorf("DiffSlice(nil) = %q, %v, want ErrNilSet", got, err)
+	}
+	if got, err := l.SymmetricDifference(nil); got != nil || !errors.Is(err, ErrNilSet) {
+		t.Errorf("SymmetricDifference(nil) = %q, %v, want ErrNilSet", got, err)
+	}
+}
+
+func TestLocalSetComparisons(t *testing.T) {
+	l := NewLocal("a", "b")
+	for _, tt := range []struct {
+		name                              string
+		other                             *LocalSet
+		equal, subset, superset, disjoint bool
+	}{
+		{"identical", NewLocal("B", "a"), true, true, true, false},
+		{"subset", NewLocal("a"), false, false, true, false},
+		{"superset", NewLocal("a", "b", "c"), false, true, false, false},
+		{"partial overlap", NewLocal("b", "c"), false, false, false, false},
+		{"disjoint", NewLocal("c"), false, false, false, true},
+		{"empty", NewLocal(), false, false, true, true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, check := range []struct {
+				name string
+				run  func(*LocalSet) (bool, error)
+				want bool
+			}{
+				{"Equal", l.Equal, tt.equal},
+				{"IsSubsetOf", l.IsSubsetOf, tt.subset},
+				{"IsSupersetOf", l.IsSupersetOf, tt.superset},
+				{"IsDisjointWith", l.IsDisjointWith, tt.disjoint},
+			} {
+				if got, err := check.run(tt.other); got != check.want || err != nil {
+					t.Errorf("%s = %v, %v, want %v", check.name, got, err, check.want)
+				}
+				if got, err := check.run(nil); got || !errors.Is(err, ErrNilSet) {
+					t.Errorf("%s(nil) = %v, %v, want ErrNilSet", check.name, got, err)
+				}
+			}
+		})
+	}
+}
+
+func TestLocalSetReads(t *testing.T) {
+	l := NewLocal("b", "C", "a")
+	if got, err := l.SliceSorted(true); !slices.Equal(got, []string{"c", "b", "a"}) || err != nil {
+		t.Errorf("SliceSorted(true) = %q, %v", got, err)
+	}
+	if got, err := l.HasAll("A", "b"); !got || err != nil {
+		t.Errorf("HasAll(A, b) = %v, %v", got, err)
+	}
+	if got, err := l.HasAll("a", "d"); got || err != nil {
+		t.Errorf("HasAll(a, d) = %v, %v", got, err)
+	}
+	if got, err := l.HasAny("d", "C"); !got || err != nil {
+		t.Errorf("HasAny(d, C) = %v, %v", got, err)
+	}
+
+	m, err := l.ToMap()
+	if err != nil || len(m) != 3 {
+		t.Fatalf("ToMap = %v, %v", m, err)
+	}
+	delete(m, "a")
+	if ok, _ := l.Has("a"); !ok {
+		t.Error("deleting from the ToMap result removed the member")
+	}
+
+	if n, err := l.RemoveMany("a", "A", "d"); n != 1 || err != nil {
+		t.Errorf("RemoveMany(a, A, d) = %d, %v, want 1", n, err)
+	}
+	if err := l.Clear(); err != nil {
+		t.Fatal(err)
+	}
+	if empty, err := l.IsEmpty(); !empty || err != nil {
+		t.Errorf("IsEmpty after Clear = %v, %v", empty, err)
+	}
+	if got, err := l.Slice(); got == nil || len(got) != 0 || err != nil {
+		t.Errorf("Slice of an empty set = %#v, %v, want an empty slice", got, err)
+	}
+}
+
+func TestLocalSetOptions(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		opts []Option
+		want []string
+	}{
+		{"default", nil, []string{"", " padded ", "mixed"}},
+		{"CaseSensitive", []Option{CaseSensitive()}, []string{"", " padded ", "MIXED", "mixed"}},
+		{"WithNormalizer", []Option{WithNormalizer(strings.ToUpper)}, []string{"", " PADDED ", "MIXED"}},
+		{"WithTrimSpace", []Option{WithTrimSpace()}, []string{"mixed", "padded"}},
+		// Options not about normalization are ignored.
+		{"other options", []Option{WithBatchSize(1), DeleteOnClose()}, []string{"", " padded ", "mixed"}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			l := NewLocalWithOptions(tt.opts...)
+			if err := l.InsertMany("mixed", "MIXED", " padded ", ""); err != nil {
+				t.Fatal(err)
+			}
+			if got := localMembers(t, l); !slices.Equal(got, tt.want) {
+				t.Errorf("members = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	strict := NewLocalWithOptions(RejectEmpty(), WithTrimSpace())
+	if err := strict.InsertMany("a", " "); !errors.Is(err, ErrEmptyElement) {
+		t.Errorf("InsertMany with a blank element = %v, want ErrEmptyElement", err)
+	}
+	if n, _ := strict.Len(); n != 0 {
+		t.Errorf("failed InsertMany left %d members", n)
+	}
+	if ok, err := strict.Has(" "); ok || err != nil {
+		t.Errorf("Has of a blank element = %v, %v", ok, err)
+	}
+}
+
+func TestDeduplicateLocal(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		input []string
+		opts  []Option
+		want  []string
+	}{
+		{"first occurrence order", []string{"b", "A", "a", "c", "B"}, nil, []string{"b", "a", "c"}},
+		{"CaseSensitive", []string{"b", "A", "a", "B"}, []Option{CaseSensitive()}, []string{"b", "A", "a", "B"}},
+		{"WithTrimSpace", []string{" a", "a ", "", "b"}, []Option{WithTrimSpace()}, []string{"a", "b"}},
+		{"empty input", nil, nil, []string{}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DeduplicateLocal(tt.input, tt.opts...)
+			if !slices.Equal(got, tt.want) || got == nil || err != nil {
+				t.Errorf("DeduplicateLocal(%q) = %#v, %v, want %q", tt.input, got, err, tt.want)
+			}
+		})
+	}
+
+	if got, err := DeduplicateLocal([]string{"a", ""}, RejectEmpty()); got != nil || !errors.Is(err, ErrEmptyElement) {
+		t.Errorf("DeduplicateLocal with an empty element = %q, %v, want ErrEmptyElement", got, err)
+	}
+}
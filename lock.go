@@ -0,0 +1,79 @@
+package redisstringset
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// unlockScript deletes the lock key only if it still holds our token, so an
+// expired lock that another client has since acquired is left alone.
+var unlockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// WithDistributedLock makes multi-command operations (Union, Subtract and
+// Intersect) hold a lock in Redis for their duration, so that Sets in
+// different processes sharing a key do not interleave them. The lock is stored
+// under the Set's key with a ":lock" suffix and expires after ttl if its holder
+// dies. An operation that finds the lock held fails immediately with
+// ErrLocked. Single-command operations such as Insert never take the lock.
+func WithDistributedLock(ttl time.Duration) Option {
+	return func(s *Set) {
+		s.lockTTL = ttl
+	}
+}
+
+func (s *Set) lockKey() string {
+	return s.key + ":lock"
+}
+
+// acquire takes the distributed lock, if enabled, and returns the function
+// releasing it.
+func (s *Set) acquire(ctx context.Context) (func(), error) {
+	if s.lockTTL <= 0 {
+		return func() {}, nil
+	}
+
+	token, err := randomToken()
+	if err != nil {
+		return nil, s.fail(fmt.Errorf("locking %s: %w", s.key, err))
+	}
+
+	lockCtx, cancel := s.withTimeout(ctx)
+	defer cancel()
+	ok, err := s.redisClient.SetNX(lockCtx, s.lockKey(), token, s.lockTTL).Result()
+	if err != nil {
+		s.logger.Printf("Error locking %s: %v", s.key, err)
+		return nil, s.fail(fmt.Errorf("locking %s: %w", s.key, classify(err)))
+	}
+	if !ok {
+		return nil, s.fail(fmt.Errorf("locking %s: %w", s.key, ErrLocked))
+	}
+
+	return func() {
+		// Release even if ctx has been cancelled; the TTL only bounds the
+		// damage of a release that never happens.
+		releaseCtx, cancel := s.withTimeout(context.Background())
+		defer cancel()
+		if err := unlockScript.Run(releaseCtx, s.redisClient, []string{s.lockKey()}, token).Err(); err != nil {
+			s.logger.Printf("Error unlocking %s: %v", s.key, err)
+		}
+	}, nil
+}
+
+// randomToken returns 32 random hex digits.
+func randomToken() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b[:]), nil
+}
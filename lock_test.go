@@ -0,0 +1,77 @@
+package redisstringset
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestDistributedLock(t *testing.T) {
+	client, f, server := newFaultyClient(t)
+	elsewhere, _ := newTestClient(t)
+	holder := newClientSet(t, client, "shared", WithDistributedLock(time.Minute))
+	contender := newClientSet(t, client, "shared", WithDistributedLock(time.Minute))
+	other := newClientSet(t, elsewhere, "other")
+	holder.InsertMany("a", "b", "c")
+	other.InsertMany("a", "b")
+
+	release, err := holder.acquire(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ttl := server.TTL("shared:lock"); ttl != time.Minute {
+		t.Errorf("lock TTL = %v, want %v", ttl, time.Minute)
+	}
+	for name, op := range map[string]func(*Set) error{
+		"Union":     func(s *Set) error { return s.Union(other) },
+		"Subtract":  func(s *Set) error { return s.Subtract(other) },
+		"Intersect": func(s *Set) error { return s.Intersect(other) },
+	} {
+		if err := op(contender); !errors.Is(err, ErrLocked) {
+			t.Errorf("%s while locked = %v, want ErrLocked", name, err)
+		}
+	}
+	if got := mustMembers(t, server, "shared"); !slices.Equal(got, []string{"a", "b", "c"}) {
+		t.Errorf("members = %v, want them untouched while locked", got)
+	}
+
+	// Single commands never take the lock.
+	locks := f.count("set")
+	if err := contender.Insert("d"); err != nil {
+		t.Errorf("Insert while locked = %v", err)
+	}
+	if n := f.count("set") - locks; n != 0 {
+		t.Errorf("Insert sent %d SET commands", n)
+	}
+
+	release()
+	if server.Exists("shared:lock") {
+		t.Error("lock survived its release")
+	}
+	if err := contender.Intersect(other); err != nil {
+		t.Errorf("Intersect after the release = %v", err)
+	}
+	if got := mustMembers(t, server, "shared"); !slices.Equal(got, []string{"a", "b"}) {
+		t.Errorf("members after Intersect = %v, want [a b]", got)
+	}
+	if server.Exists("shared:lock") {
+		t.Error("Intersect left its lock behind")
+	}
+}
+
+func TestDistributedLockKeepsForeignToken(t *testing.T) {
+	s, server := newTestSet(t, "shared", WithDistributedLock(time.Minute))
+	release, err := s.acquire(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The lock expires and another process takes it before we release ours.
+	server.FastForward(time.Minute)
+	server.Set("shared:lock", "theirs")
+	release()
+	if got, _ := server.Get("shared:lock"); got != "theirs" {
+		t.Errorf("lock = %q after our release, want the other holder's token", got)
+	}
+}
@@ -0,0 +1,208 @@
+package redisstringset
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// HasAll reports whether every element is in the Set. Elements are normalized
+// as by Has and checked with SMISMEMBER, in batches of at most the batch size,
+// stopping at the first batch with a missing element. Servers older than
+// Redis 6.2 are sent pipelined SISMEMBERs instead. By convention HasAll with
+// no elements reports true.
+func (s *Set) HasAll(elements ...string) (bool, error) {
+	return s.HasAllCtx(context.Background(), elements...)
+}
+
+// HasAllCtx is like HasAll but uses ctx for every Redis command.
+func (s *Set) HasAllCtx(ctx context.Context, elements ...string) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	members := make([]string, 0, len(elements))
+	for _, element := range elements {
+		member, ok := s.normalize(element)
+		if !ok {
+			return false, nil
+		}
+		members = append(members, member)
+	}
+	all := true
+	err := s.eachMembership(ctx, members, func(_ int, present bool) bool {
+		all = present
+		return present
+	})
+	if err != nil {
+		return false, err
+	}
+	return all, nil
+}
+
+// HasAny reports whether at least one element is in the Set, checking them as
+// HasAll does but stopping at the first batch containing a member. Elements
+// that normalize to nothing are ignored. HasAny with no elements reports
+// false.
+func (s *Set) HasAny(elements ...string) (bool, error) {
+	return s.HasAnyCtx(context.Background(), elements...)
+}
+
+// HasAnyCtx is like HasAny but uses ctx for every Redis command.
+func (s *Set) HasAnyCtx(ctx context.Context, elements ...string) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	members := make([]string, 0, len(elements))
+	for _, element := range elements {
+		if member, ok := s.normalize(element); ok {
+			members = append(members, member)
+		}
+	}
+	found := false
+	err := s.eachMembership(ctx, members, func(_ int, present bool) bool {
+		found = present
+		return !present
+	})
+	if err != nil {
+		return false, err
+	}
+	return found, nil
+}
+
+// MembershipMap reports, for each of the given elements, whether it is in the
+// Set. The result is keyed by the elements as given, before normalization, and
+// elements that normalize to the same member share that member's result. Each
+// distinct member is checked once, with SMISMEMBER batches as in HasAll.
+// Elements that normalize to nothing map to false.
+func (s *Set) MembershipMap(elements ...string) (map[string]bool, error) {
+	return s.MembershipMapCtx(context.Background(), elements...)
+}
+
+// MembershipMapCtx is like MembershipMap but uses ctx for every Redis command.
+func (s *Set) MembershipMapCtx(ctx context.Context, elements ...string) (map[string]bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	normalized, present, err := s.presence(ctx, elements)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]bool, len(elements))
+	for i, element := range elements {
+		result[element] = normalized[i].ok && present[normalized[i].member]
+	}
+	return result, nil
+}
+
+// Missing returns the elements that are not in the Set, as given and in input
+// order, checking them as MembershipMap does. Of several elements normalizing
+// to the same member only the first is returned, and elements that normalize
+// to nothing, which could not be inserted, are omitted.
+func (s *Set) Missing(elements ...string) ([]string, error) {
+	return s.MissingCtx(context.Background(), elements...)
+}
+
+// MissingCtx is like Missing but uses ctx for every Redis command.
+func (s *Set) MissingCtx(ctx context.Context, elements ...string) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	normalized, present, err := s.presence(ctx, elements)
+	if err != nil {
+		return nil, err
+	}
+	result := []string{}
+	for i, element := range elements {
+		n := normalized[i]
+		if n.ok && !present[n.member] {
+			result = append(result, element)
+			// Later elements with the same member are duplicates.
+			present[n.member] = true
+		}
+	}
+	return result, nil
+}
+
+// normalizedElement is an element's member and whether it has one, see
+// normalize.
+type normalizedElement struct {
+	member string
+	ok     bool
+}
+
+// presence normalizes elements, returning them in input order, and checks the
+// membership of each distinct member once. The caller must hold the lock.
+func (s *Set) presence(ctx context.Context, elements []string) ([]normalizedElement, map[string]bool, error) {
+	normalized := make([]normalizedElement, len(elements))
+	present := make(map[string]bool, len(elements))
+	members := make([]string, 0, len(elements))
+	for i, element := range elements {
+		member, ok := s.normalize(element)
+		normalized[i] = normalizedElement{member, ok}
+		if _, seen := present[member]; ok && !seen {
+			present[member] = false
+			members = append(members, member)
+		}
+	}
+	err := s.eachMembership(ctx, members, func(i int, ok bool) bool {
+		present[members[i]] = ok
+		return true
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return normalized, present, nil
+}
+
+// eachMembership checks members in batches of at most batchSize and calls fn
+// with each member's index and membership, in order, until fn returns false.
+// No further batches are sent once fn has returned false. The caller must hold
+// the lock.
+func (s *Set) eachMembership(ctx context.Context, members []string, fn func(i int, present bool) bool) error {
+	for start := 0; start < len(members); start += s.batchSize {
+		chunk := members[start:min(start+s.batchSize, len(members))]
+		present, err := s.memberships(ctx, chunk)
+		if err != nil {
+			s.logger.Printf("Error checking membership in %s: %v", s.key, err)
+			return s.fail(fmt.Errorf("checking membership in %s: %w", s.key, classify(err)))
+		}
+		for i, ok := range present {
+			if !fn(start+i, ok) {
+				return nil
+			}
+		}
+	}
+	return nil
+}
+
+// memberships checks a batch of members with one SMISMEMBER, or pipelined
+// SISMEMBERs on servers without it. The caller must hold the lock.
+func (s *Set) memberships(ctx context.Context, members []string) ([]bool, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	if !s.noMIsMember.Load() {
+		present, err := s.redisClient.SMIsMember(ctx, s.key, toArgs(members)...).Result()
+		if !isUnknownCommand(err) {
+			return present, err
+		}
+		s.noMIsMember.Store(true)
+	}
+
+	cmds := make([]*redis.BoolCmd, len(members))
+	_, err := s.redisClient.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, member := range members {
+			cmds[i] = pipe.SIsMember(ctx, s.key, member)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	present := make([]bool, len(cmds))
+	for i, cmd := range cmds {
+		present[i] = cmd.Val()
+	}
+	return present, nil
+}
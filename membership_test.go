@@ -0,0 +1,81 @@
+package redisstringset
+
+import (
+	"errors"
+	"maps"
+	"slices"
+	"testing"
+)
+
+func TestMembership(t *testing.T) {
+	for _, fallback := range []bool{false, true} {
+		name := "SMISMEMBER"
+		if fallback {
+			name = "SISMEMBER"
+		}
+		t.Run(name, func(t *testing.T) {
+			client, f, _ := newFaultyClient(t)
+			if fallback {
+				f.inject("smismember", -1, errUnknownCommand("smismember"))
+			}
+			s := newClientSet(t, client, "members", WithBatchSize(2), WithTrimSpace())
+			s.InsertMany("a", "b", "c")
+
+			for _, tt := range []struct {
+				name     string
+				has      func(...string) (bool, error)
+				elements []string
+				want     bool
+			}{
+				{"HasAll", s.HasAll, nil, true},
+				{"HasAll", s.HasAll, []string{"A", "b", "c"}, true},
+				{"HasAll", s.HasAll, []string{"a", "x"}, false},
+				{"HasAll", s.HasAll, []string{"a", "  "}, false},
+				{"HasAny", s.HasAny, nil, false},
+				{"HasAny", s.HasAny, []string{"x", "y", "z", "C"}, true},
+				{"HasAny", s.HasAny, []string{"x", "y"}, false},
+				{"HasAny", s.HasAny, []string{"  ", "b"}, true},
+			} {
+				if got, err := tt.has(tt.elements...); got != tt.want || err != nil {
+					t.Errorf("%s(%q) = %v, %v, want %v", tt.name, tt.elements, got, err, tt.want)
+				}
+			}
+
+			got, err := s.MembershipMap("A", "a", "x", "  ")
+			want := map[string]bool{"A": true, "a": true, "x": false, "  ": false}
+			if !maps.Equal(got, want) || err != nil {
+				t.Errorf("MembershipMap = %v, %v, want %v", got, err, want)
+			}
+			missing, err := s.Missing("x", "A", "X", "y", "  ")
+			if !slices.Equal(missing, []string{"x", "y"}) || err != nil {
+				t.Errorf("Missing = %q, %v, want [x y]", missing, err)
+			}
+
+			if fallback {
+				if n := f.count("smismember"); n != 1 {
+					t.Errorf("sent SMISMEMBER %d times, want it given up after the first", n)
+				}
+				if f.count("sismember") == 0 {
+					t.Error("no SISMEMBER sent by the fallback")
+				}
+				return
+			}
+			// HasAll stops at the first batch with a missing element.
+			before := f.count("smismember")
+			if ok, _ := s.HasAll("x", "a", "b", "c"); ok {
+				t.Error("HasAll(x, a, b, c) = true")
+			}
+			if n := f.count("smismember") - before; n != 1 {
+				t.Errorf("HasAll sent %d batches after a miss in the first, want 1", n)
+			}
+		})
+	}
+
+	dead := newDeadSet(t, "dead")
+	if _, err := dead.HasAll("a"); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("HasAll on a dead server = %v, want ErrUnavailable", err)
+	}
+	if got, err := dead.Missing("a"); got != nil || !errors.Is(err, ErrUnavailable) {
+		t.Errorf("Missing on a dead server = %v, %v, want nil and ErrUnavailable", got, err)
+	}
+}
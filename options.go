@@ -0,0 +1,237 @@
+package redisstringset
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"strings"
+	"time"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/language"
+)
+
+// Option configures a Set at construction time.
+type Option func(*Set)
+
+// Logger receives the messages a Set logs, mostly failed Redis commands, one
+// Printf call per message. *log.Logger satisfies it.
+type Logger interface {
+	Printf(format string, v ...any)
+}
+
+// WithLogger makes the Set log to logger at slog.LevelError instead of to
+// standard output with a "RedisSet: " prefix. A nil logger disables logging;
+// errors are still returned and recorded for Err.
+func WithLogger(logger *slog.Logger) Option {
+	if logger == nil {
+		return WithPrintfLogger(nil)
+	}
+	return WithPrintfLogger(slogLogger{logger})
+}
+
+// WithStdLogger is like WithLogger but logs to a *log.Logger.
+func WithStdLogger(logger *log.Logger) Option {
+	if logger == nil {
+		return WithPrintfLogger(nil)
+	}
+	return WithPrintfLogger(logger)
+}
+
+// WithPrintfLogger is like WithLogger but logs to any Logger.
+func WithPrintfLogger(logger Logger) Option {
+	return func(s *Set) {
+		if logger == nil {
+			logger = log.New(io.Discard, "", 0)
+		}
+		s.logger = logger
+	}
+}
+
+// slogLogger adapts a *slog.Logger to Logger.
+type slogLogger struct {
+	logger *slog.Logger
+}
+
+func (l slogLogger) Printf(format string, v ...any) {
+	if l.logger.Enabled(context.Background(), slog.LevelError) {
+		l.logger.Error(fmt.Sprintf(format, v...))
+	}
+}
+
+// WithTimeout bounds every Redis round trip made by the Set with a deadline of
+// d, derived from the context passed to the operation. Multi-step operations
+// such as InsertMany and Intersect apply the deadline to each round trip
+// rather than to the whole sequence. An expired deadline is reported as
+// ErrTimeout. A zero or negative d disables the timeout, which is the default.
+func WithTimeout(d time.Duration) Option {
+	return func(s *Set) {
+		s.timeout = d
+	}
+}
+
+// CaseSensitive disables the default lowercasing of elements, so that "Foo"
+// and "foo" are distinct members. It affects every path that accepts
+// elements, including InsertMany, Deduplicate and the flag.Value Set method.
+func CaseSensitive() Option {
+	return WithNormalizer(func(element string) string { return element })
+}
+
+// WithNormalizer replaces the default strings.ToLower normalization with fn.
+// It is applied exactly once to every element passed to Insert, InsertMany,
+// Has, Remove, Deduplicate and the flag.Value Set method. Members already
+// stored, such as those moved between Sets by Union, are never renormalized.
+// A nil fn restores the default.
+func WithNormalizer(fn func(string) string) Option {
+	return func(s *Set) {
+		if fn == nil {
+			fn = strings.ToLower
+		}
+		s.normalizer = fn
+	}
+}
+
+// WithCaseFolding normalizes elements with full Unicode case folding instead of
+// strings.ToLower, so that for example "Straße" and "STRASSE", or "ΣΑΣ" and
+// "σας", are the same member. Folding is language neutral: "I" folds to "i"
+// and "İ" to "i̇", leaving Turkish dotted and dotless forms distinct; use
+// WithLanguageCaseFolding for language-specific rules.
+func WithCaseFolding() Option {
+	return WithNormalizer(func(element string) string {
+		// A Caser is stateful and must not be shared between goroutines.
+		return cases.Fold().String(element)
+	})
+}
+
+// WithLanguageCaseFolding is like WithCaseFolding but first applies the
+// lowercasing rules of tag. With language.Turkish, "I" and "ı" become the
+// same member, as do "İ" and "i".
+func WithLanguageCaseFolding(tag language.Tag) Option {
+	return WithNormalizer(func(element string) string {
+		return cases.Fold().String(cases.Lower(tag).String(element))
+	})
+}
+
+// WithTrimSpace removes leading and trailing white space from every element
+// before it is normalized, uniformly for Insert, InsertMany, Has, Remove,
+// Deduplicate and the flag.Value Set method. Elements that are empty after
+// trimming are ignored: Insert and InsertMany skip them, Has reports false and
+// Remove does nothing.
+func WithTrimSpace() Option {
+	return func(s *Set) {
+		s.trimSpace = true
+	}
+}
+
+// RejectEmpty makes Insert and InsertMany fail with ErrEmptyElement for
+// elements that are empty after normalization, instead of storing the empty
+// string. InsertMany checks every element before inserting any of them. The
+// flag.Value Set method drops empty segments, so "a,,b" yields two members.
+// Combined with WithTrimSpace, white space only elements are rejected too.
+func RejectEmpty() Option {
+	return func(s *Set) {
+		s.rejectEmpty = true
+	}
+}
+
+// WithBatchSize sets the maximum number of members sent in one variadic
+// command by bulk operations such as InsertMany and RemoveMany. Values below 1
+// keep the default of 5000.
+func WithBatchSize(n int) Option {
+	return func(s *Set) {
+		if n > 0 {
+			s.batchSize = n
+		}
+	}
+}
+
+// WithScanCount sets the COUNT hint sent with each SSCAN by Each, Members,
+// Stream and the SSCAN-based retrieval of large Sets. Values below 1 keep the
+// default of 1000.
+func WithScanCount(n int) Option {
+	return func(s *Set) {
+		if n > 0 {
+			s.scanCount = n
+		}
+	}
+}
+
+// WithScanThreshold sets the cardinality above which Slice and the operations
+// built on it retrieve members with SSCAN instead of SMEMBERS. SMEMBERS is a
+// single O(N) command that stalls other clients of the server on large Sets,
+// while SSCAN spreads the work over many short commands at the cost of more
+// round trips. A positive threshold costs an SCARD before every retrieval to
+// choose between them, a threshold of 0 always uses SSCAN, and a negative one
+// never does, which is the default. Sets that may grow to millions of members
+// should set a threshold such as 10000.
+func WithScanThreshold(n int) Option {
+	return func(s *Set) {
+		s.scanThreshold = n
+	}
+}
+
+// DeleteOnClose makes Close delete the Set's key, as Destroy does, restoring
+// the behavior Close had before Destroy was added. It suits Sets used as
+// private scratch space; never use it on a key that others read.
+func DeleteOnClose() Option {
+	return func(s *Set) {
+		s.deleteOnClose = true
+	}
+}
+
+// WithSyncDelete makes Destroy, Clear, DeleteOnClose and the cleanup of the
+// Deduplicate functions delete the key with DEL, which frees its memory before
+// replying, instead of the default UNLINK.
+func WithSyncDelete() Option {
+	return func(s *Set) {
+		s.syncDelete = true
+	}
+}
+
+// normalization is the configuration mapping elements to members, set by
+// CaseSensitive, WithNormalizer, the case folding options, WithTrimSpace and
+// RejectEmpty. Set and LocalSet embed it.
+type normalization struct {
+	normalizer  func(string) string
+	trimSpace   bool
+	rejectEmpty bool
+}
+
+// defaultNormalization lowercases elements and keeps everything else as is.
+var defaultNormalization = normalization{normalizer: strings.ToLower}
+
+// normalizationOf returns the normalization configured by opts, ignoring their
+// other effects.
+func normalizationOf(opts []Option) normalization {
+	s := Set{normalization: defaultNormalization}
+	for _, opt := range opts {
+		opt(&s)
+	}
+	return s.normalization
+}
+
+// normalize maps element to the form stored in Redis. It reports false for
+// elements that must not be stored, see WithTrimSpace and RejectEmpty.
+func (n normalization) normalize(element string) (string, bool) {
+	if n.trimSpace {
+		element = strings.TrimSpace(element)
+		if element == "" {
+			return "", false
+		}
+	}
+	member := n.normalizer(element)
+	if member == "" && n.rejectEmpty {
+		return "", false
+	}
+	return member, true
+}
+
+// withTimeout derives the context for a single Redis round trip.
+func (s *Set) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if s.timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, s.timeout)
+}
@@ -0,0 +1,411 @@
+package redisstringset
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"log/slog"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"golang.org/x/text/language"
+)
+
+// slowHook delays every command by d, giving up early if its context is done.
+type slowHook struct{ d time.Duration }
+
+func (h slowHook) wait(ctx context.Context) error {
+	select {
+	case <-time.After(h.d):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (h slowHook) DialHook(next redis.DialHook) redis.DialHook { return next }
+
+func (h slowHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		if err := h.wait(ctx); err != nil {
+			cmd.SetErr(err)
+			return err
+		}
+		return next(ctx, cmd)
+	}
+}
+
+func (h slowHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		if err := h.wait(ctx); err != nil {
+			for _, cmd := range cmds {
+				cmd.SetErr(err)
+			}
+			return err
+		}
+		return next(ctx, cmds)
+	}
+}
+
+func TestWithTimeout(t *testing.T) {
+	client, _ := newTestClient(t)
+	s := newClientSet(t, client, "slow", WithTimeout(50*time.Millisecond))
+	client.AddHook(slowHook{time.Second})
+	start := time.Now()
+	if err := s.Insert("c"); !errors.Is(err, ErrTimeout) {
+		t.Errorf("Insert on a slow server = %v, want ErrTimeout", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Insert took %v despite a 50ms timeout", elapsed)
+	}
+
+	// The deadline applies to each round trip, not to the whole operation:
+	// Intersect with a Set on another client reads the receiver and then
+	// removes members, each round trip taking over half the timeout.
+	slow, _ := newTestClient(t)
+	fast, _ := newTestClient(t)
+	left := newClientSet(t, slow, "left", WithTimeout(50*time.Millisecond))
+	right := newClientSet(t, fast, "right")
+	left.InsertMany("a", "b")
+	right.InsertMany("b")
+	slow.AddHook(slowHook{30 * time.Millisecond})
+	if err := left.Intersect(right); err != nil {
+		t.Errorf("Intersect of round trips within the timeout = %v", err)
+	}
+	if got, _ := left.Slice(); len(got) != 1 || got[0] != "b" {
+		t.Errorf("receiver = %v, want [b]", got)
+	}
+}
+
+func TestCaseSensitive(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		opts  []Option
+		want  []string
+		upper bool
+	}{
+		{"default", nil, []string{"foo"}, true},
+		{"case sensitive", []Option{CaseSensitive()}, []string{"Foo", "foo"}, false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			paths := map[string]func(s *Set) error{
+				"Insert":     func(s *Set) error { s.Insert("Foo"); return s.Insert("foo") },
+				"InsertMany": func(s *Set) error { return s.InsertMany("Foo", "foo") },
+				"Set":        func(s *Set) error { return s.Set("Foo,foo") },
+			}
+			for path, insert := range paths {
+				s, server := newTestSet(t, path, tt.opts...)
+				if err := insert(s); err != nil {
+					t.Fatal(err)
+				}
+				if got := mustMembers(t, server, path); !slices.Equal(got, tt.want) {
+					t.Errorf("%s: members = %v, want %v", path, got, tt.want)
+				}
+				if ok, _ := s.Has("FOO"); ok != tt.upper {
+					t.Errorf("%s: Has(FOO) = %v, want %v", path, ok, tt.upper)
+				}
+				s.Remove("foo")
+				if got := mustMembers(t, server, path); len(got) != len(tt.want)-1 {
+					t.Errorf("%s: members after Remove(foo) = %v", path, got)
+				}
+			}
+
+			client, _ := newTestClient(t)
+			got, err := DeduplicateOrdered(client, "", []string{"Foo", "foo"}, append(tt.opts, WithLogger(nil))...)
+			if !slices.Equal(got, tt.want) || err != nil {
+				t.Errorf("DeduplicateOrdered = %v, %v, want %v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithNormalizer(t *testing.T) {
+	strip := WithNormalizer(func(element string) string { return strings.TrimPrefix(element, "user:") })
+	s, server := newTestSet(t, "stripped", strip)
+	s.Insert("user:Alice")
+	s.InsertMany("user:bob", "carol")
+	if got, want := mustMembers(t, server, "stripped"), []string{"Alice", "bob", "carol"}; !slices.Equal(got, want) {
+		t.Errorf("members = %v, want %v", got, want)
+	}
+	for element, want := range map[string]bool{"user:Alice": true, "Alice": true, "alice": false, "user:carol": true} {
+		if ok, err := s.Has(element); ok != want || err != nil {
+			t.Errorf("Has(%q) = %v, %v, want %v", element, ok, err, want)
+		}
+	}
+	s.Remove("user:bob")
+	if ok, _ := s.Has("bob"); ok {
+		t.Error("Remove(user:bob) left bob")
+	}
+
+	// A normalizer that is not idempotent shows it runs once per element.
+	mark := WithNormalizer(func(element string) string { return element + "!" })
+	client, server := newTestClient(t)
+	marked := newClientSet(t, client, "marked", mark)
+	marked.Insert("a")
+	marked.InsertMany("b")
+	marked.Set("c")
+	if got, want := mustMembers(t, server, "marked"), []string{"a!", "b!", "c!"}; !slices.Equal(got, want) {
+		t.Errorf("members = %v, want %v", got, want)
+	}
+	if ok, _ := marked.Has("a"); !ok {
+		t.Error("Has(a) = false")
+	}
+	got, err := DeduplicateOrdered(client, "", []string{"d", "d"}, mark, WithLogger(nil))
+	if !slices.Equal(got, []string{"d!"}) || err != nil {
+		t.Errorf("DeduplicateOrdered = %v, %v, want [d!]", got, err)
+	}
+
+	restored, server := newTestSet(t, "restored", WithNormalizer(nil))
+	restored.Insert("UPPER")
+	if got := mustMembers(t, server, "restored"); !slices.Equal(got, []string{"upper"}) {
+		t.Errorf("WithNormalizer(nil) stored %v, want the default lowercasing", got)
+	}
+}
+
+func TestCaseFolding(t *testing.T) {
+	tests := []struct {
+		name string
+		opt  Option
+		same [][2]string
+		diff [][2]string
+	}{
+		{"neutral", WithCaseFolding(),
+			[][2]string{{"Straße", "STRASSE"}, {"ΣΑΣ", "σας"}, {"I", "i"}},
+			[][2]string{{"I", "ı"}, {"İ", "i"}}},
+		{"Turkish", WithLanguageCaseFolding(language.Turkish),
+			[][2]string{{"I", "ı"}, {"İ", "i"}, {"Straße", "STRASSE"}},
+			[][2]string{{"I", "i"}, {"ı", "i"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, pair := range append(tt.same, tt.diff...) {
+				want := slices.Contains(tt.same, pair)
+				s, _ := newTestSet(t, "folded", tt.opt)
+				s.Insert(pair[0])
+				if ok, err := s.Has(pair[1]); ok != want || err != nil {
+					t.Errorf("Has(%q) after Insert(%q) = %v, %v, want %v", pair[1], pair[0], ok, err, want)
+				}
+
+				// Members read back fold to themselves.
+				stored, _ := s.Slice()
+				s.InsertMany(stored...)
+				if n, _ := s.Len(); n != 1 {
+					t.Errorf("reinserting %q from Slice grew the Set to %d", stored, n)
+				}
+				s.Remove(pair[1])
+				if n, _ := s.Len(); (n == 0) != want {
+					t.Errorf("Remove(%q) after Insert(%q) left %d members", pair[1], pair[0], n)
+				}
+			}
+
+			client, _ := newTestClient(t)
+			got, err := Deduplicate(client, "", []string{"Straße", "STRASSE", "strasse"}, tt.opt, WithLogger(nil))
+			if len(got) != 1 || err != nil {
+				t.Errorf("Deduplicate = %q, %v, want one member", got, err)
+			}
+		})
+	}
+}
+
+func TestRejectEmpty(t *testing.T) {
+	tests := []struct {
+		name   string
+		opts   []Option
+		insert string
+		many   []string
+		flag   string
+		err    bool
+		want   []string
+	}{
+		{"permissive", nil, "", []string{"a", "", "b"}, "c,,d", false, []string{"", "a", "b", "c", "d"}},
+		{"rejected", []Option{RejectEmpty()}, "", []string{"a", "", "b"}, "c,,d", true, []string{"c", "d"}},
+		{"white space kept", []Option{RejectEmpty()}, " ", []string{"a", " ", "b"}, "c, ,d", false, []string{" ", "a", "b", "c", "d"}},
+		{"trimmed and skipped", []Option{WithTrimSpace()}, " ", []string{"a", " ", "b"}, "c, ,d", false, []string{"a", "b", "c", "d"}},
+		{"trimmed and rejected", []Option{WithTrimSpace(), RejectEmpty()}, " ", []string{"a", " ", "b"}, "c, ,d", true, []string{"c", "d"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, server := newTestSet(t, "validated", tt.opts...)
+			if err := s.Insert(tt.insert); errors.Is(err, ErrEmptyElement) != tt.err {
+				t.Errorf("Insert(%q) = %v, want ErrEmptyElement: %v", tt.insert, err, tt.err)
+			}
+			if err := s.InsertMany(tt.many...); errors.Is(err, ErrEmptyElement) != tt.err {
+				t.Errorf("InsertMany(%q) = %v, want ErrEmptyElement: %v", tt.many, err, tt.err)
+			}
+			if err := s.Set(tt.flag); err != nil {
+				t.Errorf("Set(%q) = %v", tt.flag, err)
+			}
+			if got := mustMembers(t, server, "validated"); !slices.Equal(got, tt.want) {
+				t.Errorf("members = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithSyncDelete(t *testing.T) {
+	deletes := []struct {
+		name string
+		run  func(client *redis.Client, opts ...Option) error
+		// scratch is set if run deletes a key of its own rather than "deleted".
+		scratch bool
+	}{
+		{"Destroy", func(client *redis.Client, opts ...Option) error {
+			return newClientSet(t, client, "deleted", opts...).Destroy()
+		}, false},
+		{"Clear", func(client *redis.Client, opts ...Option) error {
+			return newClientSet(t, client, "deleted", opts...).Clear()
+		}, false},
+		{"DeleteOnClose", func(client *redis.Client, opts ...Option) error {
+			return newClientSet(t, client, "deleted", append(opts, DeleteOnClose())...).Close()
+		}, false},
+		{"Deduplicate", func(client *redis.Client, opts ...Option) error {
+			_, err := Deduplicate(client, "", []string{"a"}, append(opts, WithLogger(nil))...)
+			return err
+		}, true},
+	}
+	for _, tt := range deletes {
+		for _, mode := range []struct {
+			name        string
+			opts        []Option
+			noUnlink    bool
+			unlink, del int
+		}{
+			{"UNLINK", nil, false, 1, 0},
+			{"WithSyncDelete", []Option{WithSyncDelete()}, false, 0, 1},
+			{"without UNLINK", nil, true, 1, 1},
+		} {
+			t.Run(tt.name+"/"+mode.name, func(t *testing.T) {
+				client, f, server := newFaultyClient(t)
+				if mode.noUnlink {
+					f.inject("unlink", -1, errUnknownCommand("unlink"))
+				}
+				if !tt.scratch {
+					server.SAdd("deleted", "a")
+				}
+				if err := tt.run(client, mode.opts...); err != nil {
+					t.Fatal(err)
+				}
+				if keys := server.Keys(); len(keys) != 0 {
+					t.Errorf("keys left behind: %v", keys)
+				}
+				if unlink, del := f.count("unlink"), f.count("del"); unlink != mode.unlink || del != mode.del {
+					t.Errorf("sent %d UNLINKs and %d DELs, want %d and %d", unlink, del, mode.unlink, mode.del)
+				}
+			})
+		}
+	}
+
+	// A Set gives up on UNLINK after the server rejects it once.
+	client, f, _ := newFaultyClient(t)
+	f.inject("unlink", -1, errUnknownCommand("unlink"))
+	s := newClientSet(t, client, "deleted")
+	for range 3 {
+		if err := s.Clear(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if unlink, del := f.count("unlink"), f.count("del"); unlink != 1 || del != 3 {
+		t.Errorf("sent %d UNLINKs and %d DELs, want 1 and 3", unlink, del)
+	}
+}
+
+func TestNewWithOptions(t *testing.T) {
+	if _, err := NewWithOptions(nil, "nil"); err == nil {
+		t.Error("NewWithOptions with a nil client succeeded")
+	}
+	client, server := newTestClient(t)
+	server.Close()
+	if _, err := NewWithOptions(client, "dead"); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("NewWithOptions against a dead server = %v, want ErrUnavailable", err)
+	}
+
+	// Without options a Set behaves as one from New.
+	client, f, server := newFaultyClient(t)
+	plain := New(client, "plain")
+	s, err := NewWithOptions(client, "optioned")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, set := range []*Set{plain, s} {
+		if set.batchSize != defaultBatchSize || set.scanCount != defaultScanCount || set.scanThreshold != defaultScanThreshold ||
+			set.timeout != 0 || set.trimSpace || set.rejectEmpty || set.deleteOnClose || set.syncDelete {
+			t.Errorf("%s does not have the default configuration", set.Key())
+		}
+		set.InsertMany("Foo", " bar")
+		if err := set.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if got := mustMembers(t, server, set.Key()); !slices.Equal(got, []string{" bar", "foo"}) {
+			t.Errorf("%s = %q after Close, want lowercased, untrimmed members kept", set.Key(), got)
+		}
+	}
+
+	// Out of range values keep the defaults, and later options win.
+	s = newClientSet(t, client, "ranged", WithBatchSize(0), WithScanCount(-1), CaseSensitive(), WithNormalizer(nil))
+	if s.batchSize != defaultBatchSize || s.scanCount != defaultScanCount {
+		t.Errorf("batch size, scan count = %d, %d, want the defaults", s.batchSize, s.scanCount)
+	}
+	s.Insert("Foo")
+	if got := mustMembers(t, server, "ranged"); !slices.Equal(got, []string{"foo"}) {
+		t.Errorf("members = %q, want WithNormalizer(nil) to restore lowercasing", got)
+	}
+
+	s = newClientSet(t, client, "batched", WithBatchSize(2))
+	before := f.count("sadd")
+	s.InsertMany("a", "b", "c", "d", "e")
+	if n := f.count("sadd") - before; n != 3 {
+		t.Errorf("InsertMany with WithBatchSize(2) sent %d SADDs, want 3", n)
+	}
+
+	// The Deduplicate functions take the same options.
+	got, err := DeduplicateOrdered(client, "", []string{"A", "a", " A"}, WithLogger(nil), CaseSensitive(), WithTrimSpace())
+	if want := []string{"A", "a"}; !slices.Equal(got, want) || err != nil {
+		t.Errorf("DeduplicateOrdered with options = %q, %v, want %q", got, err, want)
+	}
+}
+
+func TestLoggers(t *testing.T) {
+	// Each Set fails one EXISTS against a dead server, logging one message.
+	const message = "Error checking existence of logged: "
+	var std, text, quiet bytes.Buffer
+	recorder := &logRecorder{}
+	for _, tt := range []struct {
+		name   string
+		opt    Option
+		logged func() string
+		// want are the parts of the logged text, empty if nothing is logged.
+		want []string
+	}{
+		{"WithStdLogger", WithStdLogger(log.New(&std, "app: ", 0)), std.String, []string{"app: " + message}},
+		{"WithPrintfLogger", WithPrintfLogger(recorder), func() string { return strings.Join(recorder.messages(), "\n") }, []string{message}},
+		{"WithLogger", WithLogger(slog.New(slog.NewTextHandler(&text, nil))), text.String, []string{"level=ERROR", `msg="` + message}},
+		// Messages are logged at slog.LevelError, so a handler above it drops them.
+		{"WithLogger above error", WithLogger(slog.New(slog.NewTextHandler(&quiet, &slog.HandlerOptions{Level: slog.LevelError + 1}))), quiet.String, nil},
+		{"nil WithStdLogger", WithStdLogger(nil), nil, nil},
+		{"nil WithPrintfLogger", WithPrintfLogger(nil), nil, nil},
+		{"nil WithLogger", WithLogger(nil), nil, nil},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			dead := newDeadSet(t, "logged", tt.opt)
+			if _, err := dead.Exists(); !errors.Is(err, ErrUnavailable) {
+				t.Errorf("Exists on a dead server = %v, want ErrUnavailable", err)
+			}
+			if tt.logged == nil {
+				return
+			}
+			got := tt.logged()
+			if n := strings.Count(got, message); n != min(len(tt.want), 1) {
+				t.Errorf("logged %q, want the message %d times", got, min(len(tt.want), 1))
+			}
+			for _, part := range tt.want {
+				if !strings.Contains(got, part) {
+					t.Errorf("logged %q, want it to contain %q", got, part)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,170 @@
+package redisstringset
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// SetPipeline queues operations on a Set to be sent in a single round trip by
+// Pipelined or TxPipelined, or on a caller's pipeline obtained with In. Its
+// methods mirror those of Set, including normalization, but return go-redis
+// commands whose results are only available once the pipeline has been
+// executed.
+type SetPipeline struct {
+	s    *Set
+	ctx  context.Context
+	pipe redis.Pipeliner
+	key  string
+	err  error
+	// wrote records that a write was queued, which WithIdleTTL refreshes.
+	wrote bool
+	// joined records that pipe belongs to the caller, see In.
+	joined bool
+}
+
+// Pipelined calls fn to queue operations on the receiver Set and sends them in
+// a single round trip, in the order they were queued. If an element is
+// rejected while queueing, for example by RejectEmpty, nothing is sent and
+// that error is returned. Otherwise the first failed command's error is
+// returned for the whole batch; the individual commands still report their
+// own results.
+func (s *Set) Pipelined(ctx context.Context, fn func(p *SetPipeline)) error {
+	return s.pipelined(ctx, s.redisClient.Pipeline(), fn)
+}
+
+// TxPipelined is like Pipelined but wraps the queued operations in MULTI/EXEC,
+// so other clients observe either all or none of them. It is the way to make
+// several operations on a Set atomic.
+func (s *Set) TxPipelined(ctx context.Context, fn func(p *SetPipeline)) error {
+	return s.pipelined(ctx, s.redisClient.TxPipeline(), fn)
+}
+
+// In returns a SetPipeline that queues operations on the receiver Set onto
+// pipe, a pipeline or MULTI/EXEC transaction of the caller's, such as one
+// passed to redis.Client.TxPipelined or redis.Tx.TxPipelined. The commands are
+// sent, interleaved with the caller's own in the order queued, when the caller
+// executes pipe; the Set neither executes nor discards it. Elements are
+// normalized as by the Set's own methods, and a rejected element, for example
+// by RejectEmpty, yields a failed command without queueing anything; see
+// SetPipeline.Err. The Set's key is read once, so operations queued after a
+// concurrent Rename still target the old key. Writes queued this way bypass
+// WithWriteBuffer, refresh WithIdleTTL with a queued PEXPIRE, and flush
+// WithLocalCache when queued rather than when executed.
+func (s *Set) In(ctx context.Context, pipe redis.Pipeliner) *SetPipeline {
+	return &SetPipeline{s: s, ctx: ctx, pipe: pipe, key: s.lockedKey(), joined: true}
+}
+
+func (s *Set) pipelined(ctx context.Context, pipe redis.Pipeliner, fn func(p *SetPipeline)) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	p := &SetPipeline{s: s, ctx: ctx, pipe: pipe, key: s.key}
+	fn(p)
+	if p.err != nil {
+		pipe.Discard()
+		return p.err
+	}
+	if p.wrote {
+		defer s.cache.flush()
+		s.touch(ctx, pipe)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		s.logger.Printf("Error executing pipeline on %s: %v", s.key, err)
+		return s.fail(fmt.Errorf("executing pipeline on %s: %w", s.key, classify(err)))
+	}
+	return nil
+}
+
+// Insert queues the insertion of element. The command's value is 1 if the
+// element was added and 0 if it was already a member or skipped.
+func (p *SetPipeline) Insert(element string) *redis.IntCmd {
+	return p.InsertMany(element)
+}
+
+// InsertMany queues the insertion of elements in one SADD. The command's value
+// is the number of elements added.
+func (p *SetPipeline) InsertMany(elements ...string) *redis.IntCmd {
+	members := make([]string, 0, len(elements))
+	for _, element := range elements {
+		member, ok, err := p.s.insertable(element)
+		if err != nil {
+			p.reject(err)
+			return redis.NewIntResult(0, err)
+		}
+		if ok {
+			members = append(members, member)
+		}
+	}
+	if len(members) == 0 {
+		return redis.NewIntResult(0, nil)
+	}
+	defer p.queuedWrite()
+	return p.pipe.SAdd(p.ctx, p.key, toArgs(members)...)
+}
+
+// Remove queues the removal of element. The command's value is 1 if the
+// element was removed and 0 otherwise.
+func (p *SetPipeline) Remove(element string) *redis.IntCmd {
+	return p.RemoveMany(element)
+}
+
+// RemoveMany queues the removal of elements in one SREM. The command's value
+// is the number of elements removed.
+func (p *SetPipeline) RemoveMany(elements ...string) *redis.IntCmd {
+	members := make([]string, 0, len(elements))
+	for _, element := range elements {
+		if member, ok := p.s.normalize(element); ok {
+			members = append(members, member)
+		}
+	}
+	if len(members) == 0 {
+		return redis.NewIntResult(0, nil)
+	}
+	defer p.queuedWrite()
+	return p.pipe.SRem(p.ctx, p.key, toArgs(members)...)
+}
+
+// Has queues a membership check for element.
+func (p *SetPipeline) Has(element string) *redis.BoolCmd {
+	member, ok := p.s.normalize(element)
+	if !ok {
+		return redis.NewBoolResult(false, nil)
+	}
+	return p.pipe.SIsMember(p.ctx, p.key, member)
+}
+
+// Len queues a cardinality check.
+func (p *SetPipeline) Len() *redis.IntCmd {
+	return p.pipe.SCard(p.ctx, p.key)
+}
+
+// Err returns the first error found while queueing, such as an element
+// rejected by RejectEmpty. Pipelined and TxPipelined return it themselves;
+// it is mostly useful with In.
+func (p *SetPipeline) Err() error {
+	return p.err
+}
+
+// queuedWrite notes that a write was queued. On a caller's pipeline it queues
+// the WithIdleTTL refresh right away, since the Set does not see the Exec.
+func (p *SetPipeline) queuedWrite() {
+	if !p.joined {
+		p.wrote = true
+		return
+	}
+	p.s.cache.flush()
+	if p.s.idleTTL > 0 {
+		p.pipe.PExpire(p.ctx, p.key, p.s.idleTTL)
+	}
+}
+
+// reject records the first error found while queueing.
+func (p *SetPipeline) reject(err error) {
+	if p.err == nil {
+		p.err = err
+	}
+}
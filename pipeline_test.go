@@ -0,0 +1,122 @@
+package redisstringset
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func TestPipelined(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		run  func(s *Set, ctx context.Context, fn func(p *SetPipeline)) error
+	}{
+		{"Pipelined", (*Set).Pipelined},
+		{"TxPipelined", (*Set).TxPipelined},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			client, f, server := newFaultyClient(t)
+			s := newClientSet(t, client, "piped", RejectEmpty())
+			ctx := context.Background()
+
+			// Each result depends on the commands queued before it.
+			var added, readded, removed, n *redis.IntCmd
+			var has *redis.BoolCmd
+			err := tt.run(s, ctx, func(p *SetPipeline) {
+				added = p.InsertMany("A", "b")
+				has = p.Has("a")
+				removed = p.Remove("B")
+				readded = p.InsertMany("a", "c")
+				n = p.Len()
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if added.Val() != 2 || !has.Val() || removed.Val() != 1 || readded.Val() != 1 || n.Val() != 2 {
+				t.Errorf("results = %d, %v, %d, %d, %d, want 2, true, 1, 1, 2",
+					added.Val(), has.Val(), removed.Val(), readded.Val(), n.Val())
+			}
+			if got := mustMembers(t, server, "piped"); !slices.Equal(got, []string{"a", "c"}) {
+				t.Errorf("members = %v, want [a c]", got)
+			}
+
+			// A rejected element sends nothing.
+			err = tt.run(s, ctx, func(p *SetPipeline) {
+				p.Insert("d")
+				p.Insert("")
+			})
+			if !errors.Is(err, ErrEmptyElement) {
+				t.Errorf("queueing an empty element = %v, want ErrEmptyElement", err)
+			}
+			if ok, _ := server.IsMember("piped", "d"); ok {
+				t.Error("a rejected batch was sent")
+			}
+
+			// A failed Exec is reported for the whole batch.
+			f.inject("srem", 1, errRefused)
+			var insert *redis.IntCmd
+			err = tt.run(s, ctx, func(p *SetPipeline) {
+				insert = p.Insert("e")
+				p.Remove("a")
+			})
+			if !errors.Is(err, ErrUnavailable) {
+				t.Errorf("failed Exec = %v, want ErrUnavailable", err)
+			}
+			if insert.Err() == nil {
+				t.Error("a command of the failed batch reports no error")
+			}
+		})
+	}
+}
+
+func TestIn(t *testing.T) {
+	client, server := newTestClient(t)
+	s := newClientSet(t, client, "joined", RejectEmpty())
+	ctx := context.Background()
+
+	var before, after, added, rejected, n *redis.IntCmd
+	var has *redis.BoolCmd
+	var queueErr error
+	_, err := client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		before = pipe.Incr(ctx, "requests")
+		p := s.In(ctx, pipe)
+		added = p.InsertMany("A", "b")
+		has = p.Has("a")
+		rejected = p.Insert("")
+		after = pipe.Incr(ctx, "requests")
+		n = p.Len()
+		queueErr = p.Err()
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if before.Val() != 1 || after.Val() != 2 {
+		t.Errorf("unrelated commands = %d, %d, want 1, 2", before.Val(), after.Val())
+	}
+	if added.Val() != 2 || !has.Val() || n.Val() != 2 {
+		t.Errorf("results = %d, %v, %d, want 2, true, 2", added.Val(), has.Val(), n.Val())
+	}
+	if !errors.Is(rejected.Err(), ErrEmptyElement) || !errors.Is(queueErr, ErrEmptyElement) {
+		t.Errorf("rejected element = %v, Err = %v, want ErrEmptyElement", rejected.Err(), queueErr)
+	}
+	if got := mustMembers(t, server, "joined"); !slices.Equal(got, []string{"a", "b"}) {
+		t.Errorf("members = %v, want [a b]", got)
+	}
+
+	// Nothing is sent until the caller executes the pipeline.
+	pipe := client.Pipeline()
+	s.In(ctx, pipe).Insert("c")
+	if ok, _ := server.IsMember("joined", "c"); ok {
+		t.Error("In sent a command before Exec")
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := server.IsMember("joined", "c"); !ok {
+		t.Error("c missing after Exec")
+	}
+}
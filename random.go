@@ -0,0 +1,138 @@
+package redisstringset
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Sample returns random members without removing them, using SRANDMEMBER. As
+// with SRANDMEMBER, a positive n returns up to n distinct members, so n at
+// least the Set's size returns every member, while a negative n returns
+// exactly -n members that may repeat. A zero n or an empty Set yields an empty
+// slice.
+func (s *Set) Sample(n int) ([]string, error) {
+	return s.SampleCtx(context.Background(), n)
+}
+
+// SampleCtx is like Sample but uses ctx for the Redis command.
+func (s *Set) SampleCtx(ctx context.Context, n int) ([]string, error) {
+	if n == 0 {
+		return []string{}, nil
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var result []string
+	err := s.attempt(ctx, func(ctx context.Context) (err error) {
+		result, err = s.redisClient.SRandMemberN(ctx, s.key, int64(n)).Result()
+		return err
+	})
+	if err != nil {
+		s.logger.Printf("Error sampling %s: %v", s.key, err)
+		return nil, s.fail(fmt.Errorf("sampling %s: %w", s.key, classify(err)))
+	}
+	if result == nil {
+		result = []string{}
+	}
+	return result, nil
+}
+
+// RandomMember returns a random member without removing it, using
+// SRANDMEMBER. It reports false if the Set is empty, which distinguishes that
+// case from an empty-string member.
+func (s *Set) RandomMember() (string, bool, error) {
+	return s.RandomMemberCtx(context.Background())
+}
+
+// RandomMemberCtx is like RandomMember but uses ctx for the Redis command.
+func (s *Set) RandomMemberCtx(ctx context.Context) (string, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var member string
+	found := true
+	err := s.attempt(ctx, func(ctx context.Context) (err error) {
+		member, err = s.redisClient.SRandMember(ctx, s.key).Result()
+		if errors.Is(err, redis.Nil) {
+			found, err = false, nil
+		}
+		return err
+	})
+	if err != nil {
+		s.logger.Printf("Error sampling %s: %v", s.key, err)
+		return "", false, s.fail(fmt.Errorf("sampling %s: %w", s.key, classify(err)))
+	}
+	return member, found, nil
+}
+
+// Pop removes and returns a random member with SPOP, so concurrent poppers
+// never receive the same member. It reports false if the Set is empty. The
+// member is returned as stored, that is, normalized. Pop is guarded by the
+// Set's CircuitBreaker but deliberately not retried, even with WithRetry: a
+// failed SPOP may still have removed the member, which a retry would lose.
+func (s *Set) Pop() (string, bool, error) {
+	return s.PopCtx(context.Background())
+}
+
+// PopCtx is like Pop but uses ctx for the Redis command.
+func (s *Set) PopCtx(ctx context.Context) (string, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	defer s.cache.flush()
+
+	var member string
+	found := true
+	err := s.once(ctx, func(ctx context.Context) (err error) {
+		member, err = written(ctx, s, func(c redis.Cmdable) *redis.StringCmd {
+			return c.SPop(ctx, s.key)
+		}).Result()
+		if errors.Is(err, redis.Nil) {
+			found, err = false, nil
+		}
+		return err
+	})
+	if err != nil {
+		s.logger.Printf("Error popping from %s: %v", s.key, err)
+		return "", false, s.fail(fmt.Errorf("popping from %s: %w", s.key, classify(err)))
+	}
+	return member, found, nil
+}
+
+// PopN removes and returns up to n random members with a single SPOP. It
+// returns fewer than n members if the Set is smaller, and an empty slice if it
+// is empty. n must be positive. Like Pop, PopN is never retried.
+func (s *Set) PopN(n int) ([]string, error) {
+	return s.PopNCtx(context.Background(), n)
+}
+
+// PopNCtx is like PopN but uses ctx for the Redis command.
+func (s *Set) PopNCtx(ctx context.Context, n int) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	defer s.cache.flush()
+	if n < 1 {
+		return nil, s.fail(fmt.Errorf("popping from %s: count %d is not positive", s.key, n))
+	}
+
+	var result []string
+	err := s.once(ctx, func(ctx context.Context) (err error) {
+		result, err = written(ctx, s, func(c redis.Cmdable) *redis.StringSliceCmd {
+			return c.SPopN(ctx, s.key, int64(n))
+		}).Result()
+		if errors.Is(err, redis.Nil) {
+			err = nil
+		}
+		return err
+	})
+	if err != nil {
+		s.logger.Printf("Error popping from %s: %v", s.key, err)
+		return nil, s.fail(fmt.Errorf("popping from %s: %w", s.key, classify(err)))
+	}
+	if result == nil {
+		result = []string{}
+	}
+	return result, nil
+}
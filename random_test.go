@@ -0,0 +1,146 @@
+package redisstringset
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestEmptyResultsAreNotErrors checks that the redis.Nil replies go-redis v9
+// returns for an empty Set or a missing member still map to empty results.
+func TestEmptyResultsAreNotErrors(t *testing.T) {
+	s, _ := newTestSet(t, "empty")
+
+	if got, err := s.Sample(3); got == nil || len(got) != 0 || err != nil {
+		t.Errorf("Sample = %#v, %v, want empty slice", got, err)
+	}
+	if got, ok, err := s.RandomMember(); ok || got != "" || err != nil {
+		t.Errorf("RandomMember = %q, %v, %v, want false", got, ok, err)
+	}
+	if got, ok, err := s.Pop(); ok || got != "" || err != nil {
+		t.Errorf("Pop = %q, %v, %v, want false", got, ok, err)
+	}
+	if got, err := s.PopN(2); got == nil || len(got) != 0 || err != nil {
+		t.Errorf("PopN = %#v, %v, want empty slice", got, err)
+	}
+	if ok, err := s.Has("a"); ok || err != nil {
+		t.Errorf("Has = %v, %v, want false", ok, err)
+	}
+	if got, err := s.Slice(); got == nil || len(got) != 0 || err != nil {
+		t.Errorf("Slice = %#v, %v, want empty slice", got, err)
+	}
+	if err := s.Err(); err != nil {
+		t.Errorf("Err = %v after empty results", err)
+	}
+
+	e, err := NewExpiring(s.redisClient, "empty:expiring", time.Minute, WithLogger(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := e.Has("a"); ok || err != nil {
+		t.Errorf("ExpiringSet.Has = %v, %v, want false", ok, err)
+	}
+}
+
+func TestSample(t *testing.T) {
+	s, server := newTestSet(t, "sampled")
+	members := []string{"a", "b", "c"}
+	s.InsertMany(members...)
+	for _, tt := range []struct {
+		n, want  int
+		distinct bool
+	}{{2, 2, true}, {5, 3, true}, {-5, 5, false}} {
+		got, err := s.Sample(tt.n)
+		if err != nil || len(got) != tt.want {
+			t.Errorf("Sample(%d) = %q, %v, want %d members", tt.n, got, err, tt.want)
+		}
+		for _, member := range got {
+			if !slices.Contains(members, member) {
+				t.Errorf("Sample(%d) returned non-member %q", tt.n, member)
+			}
+		}
+		if sorted := slices.Sorted(slices.Values(got)); tt.distinct && len(slices.Compact(sorted)) != len(got) {
+			t.Errorf("Sample(%d) = %q repeats a member", tt.n, got)
+		}
+	}
+	if member, ok, err := s.RandomMember(); !ok || err != nil || !slices.Contains(members, member) {
+		t.Errorf("RandomMember = %q, %v, %v, want a member", member, ok, err)
+	}
+	if got := mustMembers(t, server, "sampled"); !slices.Equal(got, members) {
+		t.Errorf("members = %v after sampling, want them untouched", got)
+	}
+}
+
+func TestPop(t *testing.T) {
+	s, server := newTestSet(t, "popped")
+	s.InsertMany("A", "b", "c")
+	member, ok, err := s.Pop()
+	if !ok || err != nil || !slices.Contains([]string{"a", "b", "c"}, member) {
+		t.Fatalf("Pop = %q, %v, %v, want a normalized member", member, ok, err)
+	}
+	if isMember, _ := server.IsMember("popped", member); isMember {
+		t.Errorf("Pop left %q in the Set", member)
+	}
+	rest, err := s.PopN(5)
+	if err != nil || len(rest) != 2 || slices.Contains(rest, member) {
+		t.Errorf("PopN(5) = %q, %v, want the 2 remaining members", rest, err)
+	}
+	if server.Exists("popped") {
+		t.Error("Set not empty after popping every member")
+	}
+	if _, err := s.PopN(0); err == nil {
+		t.Error("PopN(0) succeeded")
+	}
+}
+
+func TestConcurrentPop(t *testing.T) {
+	s, _ := newTestSet(t, "queue")
+	members := make([]string, 1000)
+	for i := range members {
+		members[i] = fmt.Sprintf("job-%d", i)
+	}
+	s.InsertMany(members...)
+
+	var mu sync.Mutex
+	var popped []string
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				var got []string
+				if i%2 == 0 {
+					member, ok, err := s.Pop()
+					if err != nil {
+						t.Error(err)
+						return
+					}
+					if ok {
+						got = []string{member}
+					}
+				} else {
+					var err error
+					if got, err = s.PopN(7); err != nil {
+						t.Error(err)
+						return
+					}
+				}
+				if len(got) == 0 {
+					return
+				}
+				mu.Lock()
+				popped = append(popped, got...)
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	slices.Sort(popped)
+	slices.Sort(members)
+	if !slices.Equal(popped, members) {
+		t.Errorf("popped %d members, want each of the %d exactly once", len(popped), len(members))
+	}
+}
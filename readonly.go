@@ -0,0 +1,80 @@
+package redisstringset
+
+import (
+	"context"
+	"iter"
+)
+
+// ReadOnlySet is a view of a Set that can query its members but has no
+// methods to change or delete them, for handing a shared Set to code that
+// must not modify it. It is safe for concurrent use.
+type ReadOnlySet struct {
+	s *Set
+}
+
+// ReadOnly returns a read-only view of the Set. The view shares the Set's
+// client, key, normalization, logger and other configuration, follows any
+// later Rename, and records failures in the Set's Err.
+func (s *Set) ReadOnly() *ReadOnlySet {
+	return &ReadOnlySet{s: s}
+}
+
+// Has reports whether element is a member of the Set, see Set.Has.
+func (r *ReadOnlySet) Has(element string) (bool, error) {
+	return r.s.Has(element)
+}
+
+// HasCtx is like Has but uses ctx for the Redis command.
+func (r *ReadOnlySet) HasCtx(ctx context.Context, element string) (bool, error) {
+	return r.s.HasCtx(ctx, element)
+}
+
+// Slice returns the members of the Set, see Set.Slice.
+func (r *ReadOnlySet) Slice() ([]string, error) {
+	return r.s.Slice()
+}
+
+// SliceCtx is like Slice but uses ctx for every Redis command.
+func (r *ReadOnlySet) SliceCtx(ctx context.Context) ([]string, error) {
+	return r.s.SliceCtx(ctx)
+}
+
+// Len returns the number of members in the Set.
+func (r *ReadOnlySet) Len() (int, error) {
+	return r.s.Len()
+}
+
+// LenCtx is like Len but uses ctx for the Redis command.
+func (r *ReadOnlySet) LenCtx(ctx context.Context) (int, error) {
+	return r.s.LenCtx(ctx)
+}
+
+// Each calls fn for every member of the Set, see Set.Each.
+func (r *ReadOnlySet) Each(ctx context.Context, fn func(element string) error) error {
+	return r.s.Each(ctx, fn)
+}
+
+// Members returns an iterator over the members of the Set, see Set.Members.
+func (r *ReadOnlySet) Members(ctx context.Context) iter.Seq[string] {
+	return r.s.Members(ctx)
+}
+
+// Sample returns random members of the Set, see Set.Sample.
+func (r *ReadOnlySet) Sample(n int) ([]string, error) {
+	return r.s.Sample(n)
+}
+
+// SampleCtx is like Sample but uses ctx for the Redis command.
+func (r *ReadOnlySet) SampleCtx(ctx context.Context, n int) ([]string, error) {
+	return r.s.SampleCtx(ctx, n)
+}
+
+// Err returns the first error recorded by the underlying Set, see Set.Err.
+func (r *ReadOnlySet) Err() error {
+	return r.s.Err()
+}
+
+// Key returns the Redis key backing the Set.
+func (r *ReadOnlySet) Key() string {
+	return r.s.Key()
+}
@@ -0,0 +1,66 @@
+package redisstringset
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestReadOnly(t *testing.T) {
+	client, f, server := newFaultyClient(t)
+	s := newClientSet(t, client, "flags")
+	s.InsertMany("a", "b")
+	r := s.ReadOnly()
+
+	if ok, err := r.Has("A"); !ok || err != nil {
+		t.Errorf("Has(A) = %v, %v, want the Set's normalization", ok, err)
+	}
+	if got, err := r.Slice(); len(got) != 2 || err != nil {
+		t.Errorf("Slice = %q, %v, want [a b]", got, err)
+	}
+	if n, err := r.Len(); n != 2 || err != nil {
+		t.Errorf("Len = %d, %v, want 2", n, err)
+	}
+	var each []string
+	if err := r.Each(context.Background(), func(member string) error {
+		each = append(each, member)
+		return nil
+	}); err != nil || len(each) != 2 {
+		t.Errorf("Each visited %q, %v, want both members", each, err)
+	}
+	if got := slices.Sorted(r.Members(context.Background())); !slices.Equal(got, []string{"a", "b"}) {
+		t.Errorf("Members = %q, want [a b]", got)
+	}
+	if got, err := r.Sample(1); len(got) != 1 || err != nil {
+		t.Errorf("Sample(1) = %q, %v, want a member", got, err)
+	}
+
+	// The view follows the Set and shares its errors.
+	s.Rename("renamed")
+	if r.Key() != "renamed" {
+		t.Errorf("Key after Rename = %q, want renamed", r.Key())
+	}
+	f.inject("sismember", 1, errRefused)
+	if _, err := r.Has("a"); !errors.Is(err, ErrUnavailable) || !errors.Is(s.Err(), ErrUnavailable) || r.Err() != s.Err() {
+		t.Errorf("failed Has = %v, recorded %v and %v, want ErrUnavailable shared", err, s.Err(), r.Err())
+	}
+
+	for name, mutator := range map[string]func(any) bool{
+		"Insert":  func(v any) bool { _, ok := v.(interface{ Insert(string) error }); return ok },
+		"Remove":  func(v any) bool { _, ok := v.(interface{ Remove(string) error }); return ok },
+		"Clear":   func(v any) bool { _, ok := v.(interface{ Clear() error }); return ok },
+		"Close":   func(v any) bool { _, ok := v.(interface{ Close() error }); return ok },
+		"Destroy": func(v any) bool { _, ok := v.(interface{ Destroy() error }); return ok },
+	} {
+		if !mutator(s) {
+			t.Fatalf("the check for %s misses Set's own method", name)
+		}
+		if mutator(r) {
+			t.Errorf("ReadOnlySet has a %s method", name)
+		}
+	}
+	if got := mustMembers(t, server, "renamed"); !slices.Equal(got, []string{"a", "b"}) {
+		t.Errorf("members = %q, want them untouched by the view", got)
+	}
+}
@@ -0,0 +1,82 @@
+package redisstringset
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"time"
+)
+
+// WithRetry makes the Set retry its idempotent single commands, SADD, SREM,
+// SISMEMBER, SCARD, SMEMBERS and SRANDMEMBER, including those of the Bytes
+// methods, up to maxAttempts attempts in total when they fail with
+// ErrUnavailable or ErrTimeout. The wait before the nth retry is backoff
+// doubled n-1 times, jittered to between half and all of that. Other
+// errors, such as ErrWrongType, fail at once, and the Set stops retrying once
+// ctx is done or its deadline would pass before the next attempt. Each attempt
+// gets its own WithTimeout deadline. A retried SADD or SREM whose failed
+// attempt was nonetheless applied may report fewer members added or removed
+// than it changed. SPOP is deliberately not retried, since a failed attempt
+// that was nonetheless applied would lose the members it popped. Pipelines,
+// scripts and bulk operations are not retried either.
+func WithRetry(maxAttempts int, backoff time.Duration) Option {
+	return func(s *Set) {
+		s.retryAttempts = maxAttempts
+		s.retryBackoff = backoff
+	}
+}
+
+// attempt calls fn with a context carrying the Set's per-round-trip timeout,
+// retrying as configured by WithRetry while the Set's CircuitBreaker allows.
+// It returns fn's last error, or ErrCircuitOpen.
+func (s *Set) attempt(ctx context.Context, fn func(ctx context.Context) error) error {
+	for n := 1; ; n++ {
+		err := s.once(ctx, fn)
+		if err == nil || n >= s.retryAttempts || !retryable(err) || ctx.Err() != nil {
+			return err
+		}
+		delay := s.retryDelay(n)
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) <= delay {
+			return err
+		}
+		s.logger.Printf("Retrying %s after %v: %v", s.key, delay, err)
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+// once is attempt without retries, for commands such as SPOP that must not be
+// sent twice: it makes a single attempt if the Set's CircuitBreaker allows.
+func (s *Set) once(ctx context.Context, fn func(ctx context.Context) error) error {
+	if err := s.breaker.allow(s.now()); err != nil {
+		return err
+	}
+	attemptCtx, cancel := s.withTimeout(ctx)
+	err := fn(attemptCtx)
+	cancel()
+	s.breaker.done(err, s.now())
+	if err == nil {
+		s.replay(ctx)
+	}
+	return err
+}
+
+// retryDelay returns the jittered wait before retry n, counting from 1.
+func (s *Set) retryDelay(n int) time.Duration {
+	d := s.retryBackoff << min(n-1, 30)
+	if d <= 0 {
+		return 0
+	}
+	return d/2 + rand.N(d/2+1)
+}
+
+// retryable reports whether err is a transient failure worth retrying.
+func retryable(err error) bool {
+	err = classify(err)
+	return errors.Is(err, ErrUnavailable) || errors.Is(err, ErrTimeout)
+}
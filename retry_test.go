@@ -0,0 +1,117 @@
+package redisstringset
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+var errWrongType = replyError("WRONGTYPE Operation against a key holding the wrong kind of value")
+
+func TestRetry(t *testing.T) {
+	insert := func(s *Set) error { return s.Insert("a") }
+	tests := []struct {
+		name     string
+		attempts int
+		cmd      string
+		times    int
+		err      error
+		run      func(s *Set) error
+		sent     int
+		want     error
+	}{
+		{"succeeds on the last attempt", 3, "sadd", 2, errRefused, insert, 3, nil},
+		{"gives up after the last attempt", 3, "sadd", -1, errRefused, insert, 3, ErrUnavailable},
+		{"timeouts are retried", 3, "sadd", 1, context.DeadlineExceeded, insert, 2, nil},
+		{"wrong type fails at once", 3, "sadd", -1, errWrongType, insert, 1, ErrWrongType},
+		{"disabled by default", 0, "sadd", 1, errRefused, insert, 1, ErrUnavailable},
+		{"Has", 3, "sismember", 2, errRefused, func(s *Set) error {
+			_, err := s.Has("a")
+			return err
+		}, 3, nil},
+		{"Remove", 3, "srem", 2, errRefused, func(s *Set) error { return s.Remove("a") }, 3, nil},
+		{"InsertBytes", 3, "sadd", 2, errRefused, func(s *Set) error { return s.InsertBytes([]byte("a")) }, 3, nil},
+		{"HasBytes", 3, "sismember", 2, errRefused, func(s *Set) error {
+			_, err := s.HasBytes([]byte("a"))
+			return err
+		}, 3, nil},
+		{"RemoveBytes", 3, "srem", 2, errRefused, func(s *Set) error { return s.RemoveBytes([]byte("a")) }, 3, nil},
+		{"Sample", 3, "srandmember", 2, errRefused, func(s *Set) error {
+			_, err := s.Sample(2)
+			return err
+		}, 3, nil},
+		{"RandomMember", 3, "srandmember", 2, errRefused, func(s *Set) error {
+			_, _, err := s.RandomMember()
+			return err
+		}, 3, nil},
+		{"Pop is not retried", 3, "spop", 1, errRefused, func(s *Set) error {
+			_, _, err := s.Pop()
+			return err
+		}, 1, ErrUnavailable},
+		{"PopN is not retried", 3, "spop", 1, errRefused, func(s *Set) error {
+			_, err := s.PopN(2)
+			return err
+		}, 1, ErrUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, f, _ := newFaultyClient(t)
+			var opts []Option
+			if tt.attempts > 0 {
+				opts = append(opts, WithRetry(tt.attempts, time.Millisecond))
+			}
+			s := newClientSet(t, client, "retry", opts...)
+			s.InsertMany("a", "b")
+			f.inject(tt.cmd, tt.times, tt.err)
+			sent := f.count(tt.cmd)
+
+			if err := tt.run(s); !errors.Is(err, tt.want) || (err == nil) != (tt.want == nil) {
+				t.Errorf("err = %v, want %v", err, tt.want)
+			}
+			if n := f.count(tt.cmd) - sent; n != tt.sent {
+				t.Errorf("sent %s %d times, want %d", tt.cmd, n, tt.sent)
+			}
+		})
+	}
+}
+
+func TestRetryStopsAtDeadline(t *testing.T) {
+	client, f, _ := newFaultyClient(t)
+	s := newClientSet(t, client, "retry", WithRetry(5, time.Second))
+	f.inject("sadd", -1, errRefused)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err := s.InsertCtx(ctx, "a")
+	if !errors.Is(err, ErrUnavailable) {
+		t.Errorf("err = %v, want ErrUnavailable", err)
+	}
+	// The first backoff, at least half a second, would pass the deadline.
+	if n := f.count("sadd"); n != 1 {
+		t.Errorf("sent sadd %d times, want 1", n)
+	}
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("InsertCtx took %v, past its deadline", elapsed)
+	}
+}
+
+func TestRetryStopsWhenCancelled(t *testing.T) {
+	client, f, _ := newFaultyClient(t)
+	s := newClientSet(t, client, "retry", WithRetry(5, time.Hour))
+	ctx, cancel := context.WithCancel(context.Background())
+	f.inject("sadd", -1, errRefused)
+
+	time.AfterFunc(50*time.Millisecond, cancel)
+	start := time.Now()
+	if err := s.InsertCtx(ctx, "a"); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("err = %v, want ErrUnavailable", err)
+	}
+	if n := f.count("sadd"); n != 1 {
+		t.Errorf("sent sadd %d times, want 1", n)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("InsertCtx took %v after being cancelled", elapsed)
+	}
+}
@@ -0,0 +1,247 @@
+package redisstringset
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// defaultScanCount is the COUNT hint sent with each SSCAN unless WithScanCount
+// says otherwise.
+const defaultScanCount = 1000
+
+// defaultScanThreshold makes Slice use SMEMBERS unless WithScanThreshold says
+// otherwise, sparing small Sets the SCARD that choosing between SMEMBERS and
+// SSCAN costs.
+const defaultScanThreshold = -1
+
+// errStopIteration ends an Each driven by an iterator whose consumer stopped.
+var errStopIteration = errors.New("iteration stopped")
+
+// Each calls fn for every member of the Set, fetching them page by page with
+// SSCAN so that only one page is held in memory at a time. It stops and
+// returns fn's error as is if fn fails, and stops between pages once ctx is
+// done. fn is called without the Set's lock held and may use the Set.
+//
+// SSCAN visits every member present for the whole iteration at least once.
+// Members added or removed meanwhile may or may not be visited, and a member
+// may be visited more than once if the Set changes during the iteration.
+func (s *Set) Each(ctx context.Context, fn func(element string) error) error {
+	return s.EachMatch(ctx, "", fn)
+}
+
+// EachMatch is like Each but only visits members matching the glob-style
+// pattern, filtered by the server with SSCAN MATCH. An empty pattern matches
+// every member. The pattern is sent as is: since members are stored
+// normalized, a pattern with upper-case literals matches nothing in a Set
+// using the default lower-casing normalization.
+func (s *Set) EachMatch(ctx context.Context, pattern string, fn func(element string) error) error {
+	var cursor uint64
+	for {
+		if err := ctx.Err(); err != nil {
+			return s.fail(fmt.Errorf("scanning %s: %w", s.lockedKey(), classify(err)))
+		}
+		page, next, err := s.scanPage(ctx, cursor, pattern, int64(s.scanCount))
+		if err != nil {
+			return err
+		}
+		for _, member := range page {
+			if err := fn(member); err != nil {
+				return err
+			}
+		}
+		if next == 0 {
+			return nil
+		}
+		cursor = next
+	}
+}
+
+// Members returns an iterator over the members of the Set, fetched lazily with
+// SSCAN as Each does. Breaking out of the loop stops further SSCANs. A failure
+// ends the iteration and is recorded for Err; use MembersErr to receive it
+// directly.
+func (s *Set) Members(ctx context.Context) iter.Seq[string] {
+	return func(yield func(string) bool) {
+		for member, err := range s.MembersErr(ctx) {
+			if err != nil || !yield(member) {
+				return
+			}
+		}
+	}
+}
+
+// MembersErr is like Members but yields a failure as a final pair with an
+// empty member and the error.
+func (s *Set) MembersErr(ctx context.Context) iter.Seq2[string, error] {
+	return func(yield func(string, error) bool) {
+		err := s.Each(ctx, func(member string) error {
+			if !yield(member, nil) {
+				return errStopIteration
+			}
+			return nil
+		})
+		if err != nil && !errors.Is(err, errStopIteration) {
+			yield("", err)
+		}
+	}
+}
+
+// Stream walks the Set with SSCAN in a background goroutine and sends its
+// members on the returned channel, which has the given buffer size and is
+// closed when the walk ends. The error channel then receives the failure, if
+// any, and is closed. Consumers that stop reading early must cancel ctx so
+// the goroutine can exit.
+func (s *Set) Stream(ctx context.Context, buffer int) (<-chan string, <-chan error) {
+	members := make(chan string, max(buffer, 0))
+	errc := make(chan error, 1)
+	go func() {
+		defer close(errc)
+		defer close(members)
+		err := s.Each(ctx, func(member string) error {
+			select {
+			case members <- member:
+				return nil
+			case <-ctx.Done():
+				return s.fail(fmt.Errorf("scanning %s: %w", s.lockedKey(), classify(ctx.Err())))
+			}
+		})
+		if err != nil {
+			errc <- err
+		}
+	}()
+	return members, errc
+}
+
+// SliceMatch returns the members matching the glob-style pattern, each once,
+// using SSCAN MATCH so that non-matching members are not transferred. See
+// EachMatch for how the pattern interacts with normalization.
+func (s *Set) SliceMatch(pattern string) ([]string, error) {
+	return s.SliceMatchCtx(context.Background(), pattern)
+}
+
+// SliceMatchCtx is like SliceMatch but uses ctx for every Redis command.
+func (s *Set) SliceMatchCtx(ctx context.Context, pattern string) ([]string, error) {
+	seen := make(map[string]nothing)
+	result := []string{}
+	err := s.EachMatch(ctx, pattern, func(member string) error {
+		if _, ok := seen[member]; !ok {
+			seen[member] = nothing{}
+			result = append(result, member)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ScanPage returns one page of members with SSCAN, starting at cursor, and the
+// cursor of the next page. Start with cursor 0 and stop when the returned
+// cursor is 0. count is a hint for the page size; Redis may return more or
+// fewer members, and values below 1 use the Set's scan count, see
+// WithScanCount. See Each for which members a complete walk visits.
+func (s *Set) ScanPage(cursor uint64, count int) ([]string, uint64, error) {
+	return s.ScanPageCtx(context.Background(), cursor, count)
+}
+
+// ScanPageCtx is like ScanPage but uses ctx for the Redis command.
+func (s *Set) ScanPageCtx(ctx context.Context, cursor uint64, count int) ([]string, uint64, error) {
+	if count < 1 {
+		count = s.scanCount
+	}
+	page, next, err := s.scanPage(ctx, cursor, "", int64(count))
+	if err != nil {
+		return nil, 0, err
+	}
+	if page == nil {
+		page = []string{}
+	}
+	return page, next, nil
+}
+
+// SlicePage returns up to count members starting at offset in lexicographic
+// order, using SORT ALPHA LIMIT. Pages are stable while the Set is unchanged,
+// but every call sorts the whole Set on the server, which costs O(N log N)
+// CPU there; prefer ScanPage for large Sets.
+func (s *Set) SlicePage(offset, count int) ([]string, error) {
+	return s.SlicePageCtx(context.Background(), offset, count)
+}
+
+// SlicePageCtx is like SlicePage but uses ctx for the Redis command.
+func (s *Set) SlicePageCtx(ctx context.Context, offset, count int) ([]string, error) {
+	if offset < 0 || count < 1 {
+		return []string{}, nil
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	page, err := s.redisClient.Sort(ctx, s.key, &redis.Sort{
+		Offset: int64(offset),
+		Count:  int64(count),
+		Alpha:  true,
+	}).Result()
+	if err != nil {
+		s.logger.Printf("Error sorting %s: %v", s.key, err)
+		return nil, s.fail(fmt.Errorf("sorting %s: %w", s.key, classify(err)))
+	}
+	if page == nil {
+		page = []string{}
+	}
+	return page, nil
+}
+
+// scanPage fetches the page of members matching pattern starting at cursor and
+// returns the cursor of the next page, 0 once the scan is complete.
+func (s *Set) scanPage(ctx context.Context, cursor uint64, pattern string, count int64) ([]string, uint64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.sscan(ctx, cursor, pattern, count)
+}
+
+// scanMembers assembles every member of the Set from SSCAN pages, dropping
+// the duplicates SSCAN may return. sizeHint presizes the result. The caller
+// must hold the lock.
+func (s *Set) scanMembers(ctx context.Context, sizeHint int) ([]string, error) {
+	seen := make(map[string]nothing, sizeHint)
+	result := make([]string, 0, sizeHint)
+	var cursor uint64
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, s.fail(fmt.Errorf("scanning %s: %w", s.key, classify(err)))
+		}
+		page, next, err := s.sscan(ctx, cursor, "", int64(s.scanCount))
+		if err != nil {
+			return nil, err
+		}
+		for _, member := range page {
+			if _, ok := seen[member]; !ok {
+				seen[member] = nothing{}
+				result = append(result, member)
+			}
+		}
+		if next == 0 {
+			return result, nil
+		}
+		cursor = next
+	}
+}
+
+// sscan issues one SSCAN. The caller must hold the lock.
+func (s *Set) sscan(ctx context.Context, cursor uint64, pattern string, count int64) ([]string, uint64, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	page, next, err := s.redisClient.SScan(ctx, s.key, cursor, pattern, count).Result()
+	if err != nil {
+		s.logger.Printf("Error scanning %s: %v", s.key, err)
+		return nil, 0, s.fail(fmt.Errorf("scanning %s: %w", s.key, classify(err)))
+	}
+	return page, next, nil
+}
@@ -0,0 +1,203 @@
+package redisstringset
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/goleak"
+)
+
+// newLeakTestSet returns a Set whose client holds a single connection, opened
+// before returning, so that goroutines started later are the Set's own.
+func newLeakTestSet(t *testing.T, key string, opts ...Option) *Set {
+	t.Helper()
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr(), PoolSize: 1, MaxRetries: -1})
+	t.Cleanup(func() { client.Close() })
+	return newClientSet(t, client, key, opts...)
+}
+
+func TestStreamCancelDoesNotLeak(t *testing.T) {
+	s := newLeakTestSet(t, "streamed", WithScanCount(2))
+	members := make([]string, 50)
+	for i := range members {
+		members[i] = fmt.Sprintf("m%d", i)
+	}
+	s.InsertMany(members...)
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stream, errc := s.Stream(ctx, 0)
+	<-stream
+	cancel()
+	if err := <-errc; err == nil {
+		t.Error("Stream abandoned after one member reported no error")
+	}
+
+	// A consumer walking away reads neither channel again.
+	ctx, cancel = context.WithCancel(context.Background())
+	stream, _ = s.Stream(ctx, 4)
+	<-stream
+	cancel()
+
+	for range s.Members(context.Background()) {
+		break
+	}
+}
+
+func TestBufferedCloseDoesNotLeak(t *testing.T) {
+	s := newLeakTestSet(t, "buffered", WithWriteBuffer(100, time.Hour))
+	short := newLeakTestSet(t, "flushed", WithWriteBuffer(100, time.Millisecond))
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+	if err := s.InsertMany("a", "b"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := s.Has("a"); !ok || err != nil {
+		t.Errorf("Has after Close = %v, %v, want the queued member flushed", ok, err)
+	}
+
+	short.Insert("a")
+	time.Sleep(20 * time.Millisecond)
+	if err := short.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSliceMatch(t *testing.T) {
+	s, _ := newTestSet(t, "purchases", WithScanCount(2))
+	s.InsertMany("user:1:purchase:10", "user:1:purchase:11", "user:2:purchase:20", "user:12:purchase:30", "admin:1")
+	tests := []struct {
+		pattern string
+		want    []string
+	}{
+		{"user:1:*", []string{"user:1:purchase:10", "user:1:purchase:11"}},
+		{"user:?:*", []string{"user:1:purchase:10", "user:1:purchase:11", "user:2:purchase:20"}},
+		{"user:[23]*", []string{"user:2:purchase:20"}},
+		{"*:purchase:?0", []string{"user:12:purchase:30", "user:1:purchase:10", "user:2:purchase:20"}},
+		{"", []string{"admin:1", "user:12:purchase:30", "user:1:purchase:10", "user:1:purchase:11", "user:2:purchase:20"}},
+		{"guest:*", []string{}},
+		// Members are stored lower-cased, so upper-case literals miss.
+		{"USER:*", []string{}},
+	}
+	for _, tt := range tests {
+		got, err := s.SliceMatch(tt.pattern)
+		if err != nil {
+			t.Fatal(err)
+		}
+		slices.Sort(got)
+		if !slices.Equal(got, tt.want) || got == nil {
+			t.Errorf("SliceMatch(%q) = %#v, want %#v", tt.pattern, got, tt.want)
+		}
+	}
+
+	exact, _ := newTestSet(t, "exact", CaseSensitive())
+	exact.InsertMany("USER:1", "user:2")
+	if got, _ := exact.SliceMatch("USER:*"); !slices.Equal(got, []string{"USER:1"}) {
+		t.Errorf("SliceMatch(USER:*) with CaseSensitive = %v, want [USER:1]", got)
+	}
+
+	errStop := errors.New("stop")
+	visited := 0
+	err := s.EachMatch(context.Background(), "user:*", func(string) error {
+		visited++
+		return errStop
+	})
+	if !errors.Is(err, errStop) || visited != 1 {
+		t.Errorf("EachMatch stopped by fn = %v after %d members, want errStop after 1", err, visited)
+	}
+}
+
+// sortHook is a go-redis hook answering SORT key LIMIT offset count ALPHA
+// from the members of server, since miniredis lacks SORT.
+type sortHook struct {
+	server *miniredis.Miniredis
+}
+
+func (h sortHook) DialHook(next redis.DialHook) redis.DialHook { return next }
+
+func (h sortHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		c, ok := cmd.(*redis.StringSliceCmd)
+		args := cmd.Args()
+		if !ok || cmd.Name() != "sort" || len(args) != 6 || args[2] != "limit" || args[5] != "alpha" {
+			return next(ctx, cmd)
+		}
+		members, _ := h.server.Members(args[1].(string))
+		offset, count := int(args[3].(int64)), int(args[4].(int64))
+		c.SetVal(members[min(offset, len(members)):min(offset+count, len(members))])
+		return nil
+	}
+}
+
+func (h sortHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return next
+}
+
+func TestScanPage(t *testing.T) {
+	client, server := newTestClient(t)
+	client.AddHook(sortHook{server})
+	s := newClientSet(t, client, "paged", WithScanCount(50))
+	members := make([]string, 1000)
+	for i := range members {
+		members[i] = fmt.Sprintf("m%04d", i)
+	}
+	s.InsertMany(members...)
+
+	for _, count := range []int{100, 0} {
+		seen := make(map[string]int)
+		var cursor uint64
+		pages := 0
+		for {
+			page, next, err := s.ScanPage(cursor, count)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, member := range page {
+				seen[member]++
+			}
+			pages++
+			if next == 0 {
+				break
+			}
+			cursor = next
+		}
+		if want := 1000 / max(count, 50); pages < want {
+			t.Errorf("ScanPage with count %d took %d pages, want at least %d", count, pages, want)
+		}
+		for _, member := range members {
+			if seen[member] != 1 {
+				t.Errorf("ScanPage with count %d visited %s %d times, want once", count, member, seen[member])
+			}
+		}
+	}
+
+	var walked []string
+	for offset := 0; ; offset += 100 {
+		page, err := s.SlicePage(offset, 100)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(page) == 0 {
+			break
+		}
+		walked = append(walked, page...)
+	}
+	if !slices.Equal(walked, members) {
+		t.Errorf("SlicePage walked %d members, want all %d in order", len(walked), len(members))
+	}
+	for _, tt := range []struct{ offset, count int }{{1000, 10}, {-1, 10}, {0, 0}} {
+		if page, err := s.SlicePage(tt.offset, tt.count); page == nil || len(page) != 0 || err != nil {
+			t.Errorf("SlicePage(%d, %d) = %#v, %v, want an empty page", tt.offset, tt.count, page, err)
+		}
+	}
+}
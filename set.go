@@ -2,32 +2,96 @@ package redisstringset
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"maps"
+	"math"
 	"os"
+	"reflect"
+	"slices"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
-	"github.com/go-redis/redis/v8"
+	"github.com/redis/go-redis/v9"
 )
 
 type nothing struct{}
 
+// storeCmd is a set-algebra STORE method of redis.Cmdable, such as
+// SUnionStore.
+type storeCmd func(c redis.Cmdable, ctx context.Context, destination string, keys ...string) *redis.IntCmd
+
+// membersCmd is a method of redis.Cmdable taking members, SAdd or SRem.
+type membersCmd func(c redis.Cmdable, ctx context.Context, key string, members ...interface{}) *redis.IntCmd
+
+// defaultBatchSize caps the number of members sent in one variadic command
+// unless WithBatchSize says otherwise.
+const defaultBatchSize = 5000
+
+// pipelineDepth is the number of batched commands Deduplicate sends per round
+// trip.
+const pipelineDepth = 20
+
+// Set is a set of strings stored in Redis under a single key. It is safe for
+// concurrent use, and single-command operations such as Has run in parallel.
+// Operations involving two Sets, such as Union, never hold both Sets' locks at
+// once: the other Set is read first and the receiver is locked afterwards, so
+// a.Union(b) and b.Union(a) may run concurrently.
+//
+// The Set's internal locking is not part of its API. Callers that need several
+// operations to appear atomic should queue them with TxPipelined, or guard
+// them with their own sync.Mutex if only other goroutines matter.
 type Set struct {
-	sync.Mutex
-	redisClient *redis.Client
-	key         string
-	logger      *log.Logger
+	// mu is held shared by operations that issue independent, server-side
+	// atomic commands and exclusively by Close, by Rename, which changes key,
+	// and by Intersect, whose local read-modify-write must not interleave
+	// with other operations on the Set.
+	mu            sync.RWMutex
+	redisClient   redis.Cmdable
+	key           string
+	logger        Logger
+	timeout       time.Duration
+	lockTTL       time.Duration
+	idleTTL       time.Duration
+	now           func() time.Time
+	batchSize     int
+	scanCount     int
+	scanThreshold int
+	deleteOnClose bool
+	syncDelete    bool
+	hashTag       string
+	retryAttempts int
+	retryBackoff  time.Duration
+	breaker       *CircuitBreaker
+	fallback      *fallback
+	cache         *memberCache
+	buffer        *writeBuffer
+	closed        bool
+
+	// normalization maps elements to members, see normalize.
+	normalization
+
+	// noInterCard, noCopy, noMIsMember and noUnlink record that the server
+	// lacks SINTERCARD, COPY, SMISMEMBER and UNLINK respectively. clustered
+	// records that it rejected a command's keys with CROSSSLOT.
+	noInterCard atomic.Bool
+	noCopy      atomic.Bool
+	noMIsMember atomic.Bool
+	noUnlink    atomic.Bool
+	clustered   atomic.Bool
+
+	errMu sync.Mutex
+	err   error
 }
 
 // New returns a Set backed by Redis, containing the values provided in the arguments.
-func New(redisClient *redis.Client, key string, initial ...string) *Set {
-	logger := log.New(os.Stdout, "RedisSet: ", log.LstdFlags)
-	s := &Set{
-		redisClient: redisClient,
-		key:         key,
-		logger:      logger,
-	}
+// Failures while seeding the initial values are logged; use NewE to have them
+// returned instead.
+func New(redisClient redis.Cmdable, key string, initial ...string) *Set {
+	s := newSet(redisClient, key)
 
 	if len(initial) > 0 {
 		s.InsertMany(initial...)
@@ -35,141 +99,1365 @@ func New(redisClient *redis.Client, key string, initial ...string) *Set {
 	return s
 }
 
+// NewE is like New but verifies the client with a PING and fails fast if the
+// client is nil, unreachable, or any of the initial values cannot be inserted.
+// Values inserted before a seeding failure are left in Redis.
+func NewE(redisClient redis.Cmdable, key string, initial ...string) (*Set, error) {
+	s, err := NewWithOptions(redisClient, key)
+	if err != nil {
+		return nil, err
+	}
+	if len(initial) > 0 {
+		if err := s.InsertMany(initial...); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// NewWithOptions returns a Set bound to key and configured by opts. Like NewE,
+// it fails if the client is nil or does not answer a PING.
+func NewWithOptions(redisClient redis.Cmdable, key string, opts ...Option) (*Set, error) {
+	if isNilClient(redisClient) {
+		return nil, errors.New("nil redis client")
+	}
+	s := newSet(redisClient, key, opts...)
+
+	ctx, cancel := s.withTimeout(context.Background())
+	defer cancel()
+	if err := redisClient.Ping(ctx).Err(); err != nil {
+		return nil, fmt.Errorf("pinging redis for %s: %w", key, classify(err))
+	}
+	return s, nil
+}
+
+// FromSlice is like NewWithOptions but also loads values into the Set with
+// chunked, pipelined SADDs, see WithBatchSize. Members already stored under key
+// are kept. If loading fails part way through, the error is a *BatchError and
+// the members sent before the failure are left in Redis.
+func FromSlice(redisClient redis.Cmdable, key string, values []string, opts ...Option) (*Set, error) {
+	return FromSliceCtx(context.Background(), redisClient, key, values, opts...)
+}
+
+// FromSliceCtx is like FromSlice but uses ctx for every Redis command.
+func FromSliceCtx(ctx context.Context, redisClient redis.Cmdable, key string, values []string, opts ...Option) (*Set, error) {
+	s, err := NewWithOptions(redisClient, key, opts...)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if _, err := s.insertPipelined(ctx, values); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// FromMap is like FromSlice but loads the keys of values.
+func FromMap(redisClient redis.Cmdable, key string, values map[string]struct{}, opts ...Option) (*Set, error) {
+	return FromMapCtx(context.Background(), redisClient, key, values, opts...)
+}
+
+// FromMapCtx is like FromMap but uses ctx for every Redis command.
+func FromMapCtx(ctx context.Context, redisClient redis.Cmdable, key string, values map[string]struct{}, opts ...Option) (*Set, error) {
+	return FromSliceCtx(ctx, redisClient, key, slices.Collect(maps.Keys(values)), opts...)
+}
+
+// derive returns a new Set bound to key, which must already carry any hash
+// tag, that shares the receiver's client and configuration.
+func (s *Set) derive(key string) *Set {
+	return &Set{
+		redisClient:   s.redisClient,
+		key:           key,
+		logger:        s.logger,
+		timeout:       s.timeout,
+		normalization: s.normalization,
+		lockTTL:       s.lockTTL,
+		idleTTL:       s.idleTTL,
+		syncDelete:    s.syncDelete,
+		hashTag:       s.hashTag,
+		retryAttempts: s.retryAttempts,
+		retryBackoff:  s.retryBackoff,
+		breaker:       s.breaker,
+		fallback:      s.fallback.clone(),
+		cache:         s.cache.clone(),
+		buffer:        s.buffer.clone(),
+		now:           s.now,
+		batchSize:     s.batchSize,
+		scanCount:     s.scanCount,
+		scanThreshold: s.scanThreshold,
+	}
+}
+
+func newSet(redisClient redis.Cmdable, key string, opts ...Option) *Set {
+	logger := log.New(os.Stdout, "RedisSet: ", log.LstdFlags)
+	s := &Set{
+		redisClient:   redisClient,
+		key:           key,
+		logger:        logger,
+		normalization: defaultNormalization,
+		now:           time.Now,
+		batchSize:     defaultBatchSize,
+		scanCount:     defaultScanCount,
+		scanThreshold: defaultScanThreshold,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.key = s.tagged(key)
+	return s
+}
+
 // Deduplicate utilizes the Set type to generate a unique list of strings from the input slice.
-func Deduplicate(redisClient *redis.Client, key string, input []string) []string {
-	ss := New(redisClient, key, input...)
-	defer ss.Close()
+// The temporary key is deleted before returning, including when an insert or
+// the final retrieval fails; a failed deletion is also reported as an error.
+// If key is empty a unique key under the "redisstringset:tmp:" prefix is used,
+// so concurrent calls cannot see each other's elements. A given key must not
+// exist: rather than mixing its members into the result and deleting them,
+// every Deduplicate function then fails with ErrKeyExists, leaving it intact.
+// The input is sent in pipelined batches of variadic SADDs followed by a single
+// SMEMBERS, so large inputs cost few round trips.
+func Deduplicate(redisClient redis.Cmdable, key string, input []string, opts ...Option) ([]string, error) {
+	return DeduplicateCtx(context.Background(), redisClient, key, input, opts...)
+}
 
-	return ss.Slice()
+// DeduplicateCtx is like Deduplicate but uses ctx for every Redis command.
+// The temporary key is deleted with a fresh context so that cleanup still
+// happens when ctx has been cancelled.
+func DeduplicateCtx(ctx context.Context, redisClient redis.Cmdable, key string, input []string, opts ...Option) ([]string, error) {
+	result, _, err := DeduplicateWithStatsCtx(ctx, redisClient, key, input, opts...)
+	return result, err
+}
+
+// DeduplicateStats describes the work done by DeduplicateWithStats.
+type DeduplicateStats struct {
+	// Input is the number of input elements.
+	Input int
+	// Skipped is the number of elements dropped by normalization, such as
+	// those left empty by WithTrimSpace.
+	Skipped int
+	// Unique is the number of distinct members, as counted by SADD.
+	Unique int
+	// Duplicates is the number of remaining elements whose member had
+	// already been seen.
+	Duplicates int
+}
+
+// DeduplicateWithStats is like Deduplicate but also reports how many input
+// elements were unique and how many were dropped. The stats are zero when an
+// error is returned.
+func DeduplicateWithStats(redisClient redis.Cmdable, key string, input []string, opts ...Option) ([]string, DeduplicateStats, error) {
+	return DeduplicateWithStatsCtx(context.Background(), redisClient, key, input, opts...)
+}
+
+// DeduplicateWithStatsCtx is like DeduplicateWithStats but uses ctx for every
+// Redis command, as DeduplicateCtx does.
+func DeduplicateWithStatsCtx(ctx context.Context, redisClient redis.Cmdable, key string, input []string, opts ...Option) (result []string, stats DeduplicateStats, err error) {
+	ss, err := newScratchSet(ctx, redisClient, key, opts)
+	if err != nil {
+		return nil, DeduplicateStats{}, err
+	}
+	defer func() {
+		if cerr := ss.Destroy(); cerr != nil && err == nil {
+			result, stats, err = nil, DeduplicateStats{}, cerr
+		}
+	}()
+
+	ss.mu.RLock()
+	defer ss.mu.RUnlock()
+	if stats, err = ss.insertPipelined(ctx, input); err != nil {
+		return nil, DeduplicateStats{}, err
+	}
+	if result, err = ss.members(ctx); err != nil {
+		return nil, DeduplicateStats{}, err
+	}
+	return result, stats, nil
+}
+
+// DeduplicateOrdered is like Deduplicate but returns the normalized members in
+// the order of their first occurrence in input.
+func DeduplicateOrdered(redisClient redis.Cmdable, key string, input []string, opts ...Option) ([]string, error) {
+	return DeduplicateOrderedCtx(context.Background(), redisClient, key, input, opts...)
+}
+
+// DeduplicateOrderedCtx is like DeduplicateOrdered but uses ctx for every
+// Redis command and stops between batches once ctx is done.
+func DeduplicateOrderedCtx(ctx context.Context, redisClient redis.Cmdable, key string, input []string, opts ...Option) ([]string, error) {
+	return deduplicateInOrder(ctx, redisClient, key, input, opts, func(_, member string) string { return member })
+}
+
+// DeduplicateKeepCase is like Deduplicate but returns the original input
+// strings rather than their normalized forms. Membership is still decided on
+// the normalized form, the first occurrence of each member wins, and the
+// result keeps the input order.
+func DeduplicateKeepCase(redisClient redis.Cmdable, key string, input []string, opts ...Option) ([]string, error) {
+	return DeduplicateKeepCaseCtx(context.Background(), redisClient, key, input, opts...)
+}
+
+// DeduplicateKeepCaseCtx is like DeduplicateKeepCase but uses ctx for every
+// Redis command and stops between batches once ctx is done.
+func DeduplicateKeepCaseCtx(ctx context.Context, redisClient redis.Cmdable, key string, input []string, opts ...Option) ([]string, error) {
+	return deduplicateInOrder(ctx, redisClient, key, input, opts, func(element, _ string) string { return element })
+}
+
+// deduplicateInOrder inserts input into a temporary Set and collects, in input
+// order, pick's choice between the original element and its member for each
+// member that was new.
+func deduplicateInOrder(ctx context.Context, redisClient redis.Cmdable, key string, input []string, opts []Option, pick func(element, member string) string) (result []string, err error) {
+	ss, err := newScratchSet(ctx, redisClient, key, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if cerr := ss.Destroy(); cerr != nil && err == nil {
+			result, err = nil, cerr
+		}
+	}()
+	ss.mu.RLock()
+	defer ss.mu.RUnlock()
+
+	result = []string{}
+	err = ss.insertEach(ctx, input, func(element, member string) {
+		result = append(result, pick(element, member))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// DeduplicateStream reads elements from in until it is closed and sends each
+// normalized member to out the first time it is seen, then closes out. Elements
+// are inserted into a temporary Set in pipelined batches of up to the batch
+// size, a batch being sent early whenever in has nothing ready, so memory use
+// is bounded by the batch size rather than the input. The function returns
+// once in is drained or ctx is done, deleting the temporary key as Deduplicate
+// does.
+func DeduplicateStream(ctx context.Context, redisClient redis.Cmdable, key string, in <-chan string, out chan<- string, opts ...Option) (err error) {
+	defer close(out)
+	ss, err := newScratchSet(ctx, redisClient, key, opts)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := ss.Destroy(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}()
+	ss.mu.RLock()
+	defer ss.mu.RUnlock()
+
+	var (
+		chunk     = make([]string, 0, ss.batchSize)
+		fresh     = make([]string, 0, ss.batchSize)
+		processed int
+	)
+	for open := true; open; {
+		chunk, open, err = receive(ctx, in, chunk[:0], ss.batchSize)
+		if err != nil {
+			return ss.fail(fmt.Errorf("inserting into %s: %w", ss.key, classify(err)))
+		}
+		fresh = fresh[:0]
+		n, berr := ss.insertBatch(ctx, chunk, processed, func(_, member string) {
+			fresh = append(fresh, member)
+		})
+		if berr != nil {
+			return berr
+		}
+		processed += n
+		for _, member := range fresh {
+			select {
+			case out <- member:
+			case <-ctx.Done():
+				return ss.fail(fmt.Errorf("inserting into %s: %w", ss.key, classify(ctx.Err())))
+			}
+		}
+	}
+	return nil
+}
+
+// receive appends to chunk the next element from in, waiting for it, and then
+// any further elements that are ready, up to limit in total. It reports false
+// once in is closed.
+func receive(ctx context.Context, in <-chan string, chunk []string, limit int) ([]string, bool, error) {
+	select {
+	case element, ok := <-in:
+		if !ok {
+			return chunk, false, nil
+		}
+		chunk = append(chunk, element)
+	case <-ctx.Done():
+		return chunk, false, ctx.Err()
+	}
+	for len(chunk) < limit {
+		select {
+		case element, ok := <-in:
+			if !ok {
+				return chunk, false, nil
+			}
+			chunk = append(chunk, element)
+		default:
+			return chunk, true, nil
+		}
+	}
+	return chunk, true, nil
 }
 
-func (s *Set) Close() {
-	s.Lock()
-	defer s.Unlock()
+// tempKeyPrefix prefixes the keys generated for Deduplicate when none is given.
+const tempKeyPrefix = "redisstringset:tmp:"
 
-	if _, err := s.redisClient.Del(context.Background(), s.key).Result(); err != nil {
+// newScratchSet returns the temporary Set used by the Deduplicate functions,
+// generating a unique key if key is empty and otherwise checking that key
+// does not exist.
+func newScratchSet(ctx context.Context, redisClient redis.Cmdable, key string, opts []Option) (*Set, error) {
+	if key == "" {
+		token, err := randomToken()
+		if err != nil {
+			return nil, fmt.Errorf("generating temporary key: %w", err)
+		}
+		return newSet(redisClient, tempKeyPrefix+token, opts...), nil
+	}
+	ss := newSet(redisClient, key, opts...)
+	ctx, cancel := ss.withTimeout(ctx)
+	defer cancel()
+	n, err := redisClient.Exists(ctx, ss.key).Result()
+	if err != nil {
+		ss.logger.Printf("Error checking existence of %s: %v", ss.key, err)
+		return nil, fmt.Errorf("checking existence of %s: %w", ss.key, classify(err))
+	}
+	if n > 0 {
+		return nil, fmt.Errorf("deduplicating into %s: %w", ss.key, ErrKeyExists)
+	}
+	return ss, nil
+}
+
+// Close releases the receiver Set. It leaves the key and its members in
+// Redis, so other Sets and processes sharing the key are unaffected; use
+// Destroy to delete them. Sets created with DeleteOnClose instead destroy
+// their key on the first successful Close, as every Set did before Destroy
+// existed. Sets created with WithWriteBuffer send their queued writes first,
+// unless they are about to be deleted. Close never closes the Redis client,
+// which belongs to the caller, and the Set's methods keep working after it.
+func (s *Set) Close() error {
+	return s.CloseCtx(context.Background())
+}
+
+// CloseCtx is like Close but uses ctx for the Redis commands of DeleteOnClose
+// and WithWriteBuffer.
+func (s *Set) CloseCtx(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.deleteOnClose || s.closed {
+		return s.flushBuffer(ctx)
+	}
+	if err := s.del(ctx); err != nil {
+		return err
+	}
+	s.closed = true
+	return nil
+}
+
+// Destroy deletes the key backing the receiver Set, removing every member for
+// all Sets and processes sharing the key. Deleting a key that does not exist
+// is not an error. The key is deleted with UNLINK, which frees large Sets on a
+// background thread instead of blocking the server, falling back to DEL on
+// servers older than Redis 4; see also WithSyncDelete.
+func (s *Set) Destroy() error {
+	return s.DestroyCtx(context.Background())
+}
+
+// DestroyCtx is like Destroy but uses ctx for the Redis command.
+func (s *Set) DestroyCtx(ctx context.Context) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.del(ctx)
+}
+
+// Clear removes every member from the receiver Set by deleting its key, which
+// the next insert recreates. It is the same command as Destroy, named for
+// callers that keep using the Set; clearing an empty Set is not an error.
+func (s *Set) Clear() error {
+	return s.ClearCtx(context.Background())
+}
+
+// ClearCtx is like Clear but uses ctx for the Redis command.
+func (s *Set) ClearCtx(ctx context.Context) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.del(ctx)
+}
+
+// drainScript returns the members of a set and deletes it. Unlike a MULTI/EXEC
+// of the same commands, a failing SMEMBERS, such as on a key of another type,
+// aborts the script before the DEL.
+var drainScript = redis.NewScript(`
+local members = redis.call("SMEMBERS", KEYS[1])
+redis.call("DEL", KEYS[1])
+return members
+`)
+
+// SliceAndClear atomically returns every member of the receiver Set and
+// empties it, with a Lua script running SMEMBERS and DEL, so that members
+// inserted concurrently are either returned or left in the Set, never lost.
+// Unlike Slice it always uses SMEMBERS, whatever WithScanThreshold says, since
+// SSCAN cannot be made atomic. Since the key is deleted there is no TTL for
+// WithIdleTTL to refresh; the next write starts one.
+func (s *Set) SliceAndClear() ([]string, error) {
+	return s.SliceAndClearCtx(context.Background())
+}
+
+// SliceAndClearCtx is like SliceAndClear but uses ctx for the Redis command.
+func (s *Set) SliceAndClearCtx(ctx context.Context) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	defer s.cache.flush()
+	s.buffer.discard()
+	s.fallback.flush()
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	result, err := drainScript.Run(ctx, s.redisClient, []string{s.key}).StringSlice()
+	if err != nil {
+		s.logger.Printf("Error draining %s: %v", s.key, err)
+		return nil, s.fail(fmt.Errorf("draining %s: %w", s.key, classify(err)))
+	}
+	if result == nil {
+		result = []string{}
+	}
+	return result, nil
+}
+
+// del deletes the Set's key, with UNLINK unless the server lacks it or
+// WithSyncDelete is set. The caller must hold the lock.
+func (s *Set) del(ctx context.Context) error {
+	defer s.cache.flush()
+	s.buffer.discard()
+	s.fallback.flush()
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+	if !s.syncDelete && !s.noUnlink.Load() {
+		err := s.redisClient.Unlink(ctx, s.key).Err()
+		if !isUnknownCommand(err) {
+			if err != nil {
+				s.logger.Printf("Error deleting key %s: %v", s.key, err)
+				return s.fail(fmt.Errorf("deleting key %s: %w", s.key, classify(err)))
+			}
+			return nil
+		}
+		s.noUnlink.Store(true)
+	}
+	if _, err := s.redisClient.Del(ctx, s.key).Result(); err != nil {
 		s.logger.Printf("Error deleting key %s: %v", s.key, err)
+		return s.fail(fmt.Errorf("deleting key %s: %w", s.key, classify(err)))
 	}
+	return nil
 }
 
 // Has returns true if the receiver Set already contains the element string argument.
-func (s *Set) Has(element string) bool {
-	s.Lock()
-	defer s.Unlock()
+// A failed membership check is reported as an error rather than as absence.
+func (s *Set) Has(element string) (bool, error) {
+	return s.HasCtx(context.Background(), element)
+}
 
-	result, err := s.redisClient.SIsMember(context.Background(), s.key, strings.ToLower(element)).Result()
-	if err != nil {
-		s.logger.Printf("Error checking membership for %s: %v", element, err)
-		return false
+// HasCtx is like Has but uses ctx for the Redis command.
+func (s *Set) HasCtx(ctx context.Context, element string) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	member, ok := s.normalize(element)
+	if !ok {
+		return false, nil
 	}
-	return result
+	return s.hasMember(ctx, member)
+}
+
+// Insert adds the element string argument to the receiver Set. The error from
+// Redis, if any, is returned wrapped with the key and element.
+func (s *Set) Insert(element string) error {
+	return s.InsertCtx(context.Background(), element)
+}
+
+// InsertCtx is like Insert but uses ctx for the Redis command.
+func (s *Set) InsertCtx(ctx context.Context, element string) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	member, ok, err := s.insertable(element)
+	if !ok {
+		return err
+	}
+	if s.buffer != nil {
+		return s.enqueue(ctx, []string{member}, true)
+	}
+	_, err = s.insertMember(ctx, member)
+	return err
+}
+
+// InsertMany adds all the elements strings into the receiver Set with variadic
+// SADD commands of at most 5000 members each, see WithBatchSize. With
+// RejectEmpty, an empty element fails the whole call before anything is
+// inserted. A failure after some commands succeeded is a *BatchError.
+// Calling it without elements does nothing.
+func (s *Set) InsertMany(elements ...string) error {
+	return s.InsertManyCtx(context.Background(), elements...)
+}
+
+// InsertManyCtx is like InsertMany but uses ctx for every Redis command and
+// stops between commands once ctx is done.
+func (s *Set) InsertManyCtx(ctx context.Context, elements ...string) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	members := make([]string, 0, len(elements))
+	for _, i := range elements {
+		member, ok, err := s.insertable(i)
+		if err != nil {
+			return err
+		}
+		if ok {
+			members = append(members, member)
+		}
+	}
+	return s.insertMembers(ctx, members)
+}
+
+// insertMembers adds already normalized members for InsertMany and the
+// flag.Value Set method, queueing them with WithWriteBuffer and recording
+// them in degraded mode if Redis is unavailable. The caller must hold the
+// lock.
+func (s *Set) insertMembers(ctx context.Context, members []string) error {
+	if s.buffer != nil {
+		return s.enqueue(ctx, members, true)
+	}
+	_, err := s.addMembers(ctx, members)
+	var batchErr *BatchError
+	if errors.As(err, &batchErr) && s.degrade(err, members[batchErr.Processed:]...) {
+		return nil
+	}
+	return err
 }
 
-// Insert adds the element string argument to the receiver Set.
-func (s *Set) Insert(element string) {
-	s.Lock()
-	defer s.Unlock()
+// Remove will delete the element string from the receiver Set. The error from
+// Redis, if any, is returned wrapped with the key and element.
+func (s *Set) Remove(element string) error {
+	return s.RemoveCtx(context.Background(), element)
+}
+
+// RemoveCtx is like Remove but uses ctx for the Redis command.
+func (s *Set) RemoveCtx(ctx context.Context, element string) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 
-	if _, err := s.redisClient.SAdd(context.Background(), s.key, strings.ToLower(element)).Result(); err != nil {
-		s.logger.Printf("Error inserting %s into %s: %v", element, s.key, err)
+	member, ok := s.normalize(element)
+	if !ok {
+		return nil
+	}
+	if s.buffer != nil {
+		return s.enqueue(ctx, []string{member}, false)
 	}
+	return s.removeMember(ctx, member)
 }
 
-// InsertMany adds all the elements strings into the receiver Set.
-func (s *Set) InsertMany(elements ...string) {
-	s.Lock()
-	defer s.Unlock()
+// RemoveMany deletes all the elements strings from the receiver Set and returns
+// how many of them were members. The elements are sent in variadic SREM
+// commands of at most 5000 members each, see WithBatchSize; a failure after
+// some commands succeeded is a *BatchError. Calling it without elements does
+// nothing.
+func (s *Set) RemoveMany(elements ...string) (int, error) {
+	return s.RemoveManyCtx(context.Background(), elements...)
+}
+
+// RemoveManyCtx is like RemoveMany but uses ctx for every Redis command and
+// stops between commands once ctx is done.
+func (s *Set) RemoveManyCtx(ctx context.Context, elements ...string) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	members := make([]string, 0, len(elements))
 	for _, i := range elements {
+		if member, ok := s.normalize(i); ok {
+			members = append(members, member)
+		}
+	}
+	if err := s.flushBuffer(ctx); err != nil {
+		return 0, err
+	}
+	removed, err := s.removeMembers(ctx, members)
+	return int(removed), err
+}
+
+// Slice returns a string slice that contains all the elements in the Set. An
+// empty Set yields a non-nil empty slice; a failed retrieval yields a nil slice
+// and the error. With WithScanThreshold, Sets larger than the threshold are
+// assembled from SSCAN pages so that no single command blocks the server for
+// long; each member still appears exactly once.
+func (s *Set) Slice() ([]string, error) {
+	return s.SliceCtx(context.Background())
+}
+
+// SliceCtx is like Slice but uses ctx for the Redis command.
+func (s *Set) SliceCtx(ctx context.Context) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.members(ctx)
+}
 
-		s.Insert(i)
+// ToMap is like Slice but returns the members as the keys of a map, for use
+// with native Go set idioms. The map is sized for the retrieved members
+// before it is filled.
+func (s *Set) ToMap() (map[string]struct{}, error) {
+	return s.ToMapCtx(context.Background())
+}
+
+// ToMapCtx is like ToMap but uses ctx for every Redis command.
+func (s *Set) ToMapCtx(ctx context.Context) (map[string]struct{}, error) {
+	members, err := s.SliceCtx(ctx)
+	if err != nil {
+		return nil, err
 	}
+	result := make(map[string]struct{}, len(members))
+	for _, member := range members {
+		result[member] = struct{}{}
+	}
+	return result, nil
 }
 
-// Remove will delete the element string from the receiver Set.
-func (s *Set) Remove(element string) {
-	s.Lock()
-	defer s.Unlock()
+// ToBoolMap is like ToMap but maps every member to true.
+func (s *Set) ToBoolMap() (map[string]bool, error) {
+	return s.ToBoolMapCtx(context.Background())
+}
 
-	if _, err := s.redisClient.SRem(context.Background(), s.key, strings.ToLower(element)).Result(); err != nil {
-		s.logger.Printf("Error removing %s from %s: %v", element, s.key, err)
+// ToBoolMapCtx is like ToBoolMap but uses ctx for every Redis command.
+func (s *Set) ToBoolMapCtx(ctx context.Context) (map[string]bool, error) {
+	members, err := s.SliceCtx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]bool, len(members))
+	for _, member := range members {
+		result[member] = true
 	}
+	return result, nil
 }
 
-// Slice returns a string slice that contains all the elements in the Set.
-func (s *Set) Slice() []string {
-	s.Lock()
-	defer s.Unlock()
+// SliceSorted is like Slice but returns the members in ascending byte-wise
+// lexicographic order, or descending order if descending is true. The members
+// are sorted client-side after a single SMEMBERS, which keeps the cost off the
+// server.
+func (s *Set) SliceSorted(descending bool) ([]string, error) {
+	return s.SliceSortedCtx(context.Background(), descending)
+}
 
-	result, err := s.redisClient.SMembers(context.Background(), s.key).Result()
+// SliceSortedCtx is like SliceSorted but uses ctx for the Redis command.
+func (s *Set) SliceSortedCtx(ctx context.Context, descending bool) ([]string, error) {
+	result, err := s.SliceCtx(ctx)
 	if err != nil {
-		s.logger.Printf("Error retrieving members for %s: %v", s.key, err)
-		return []string{}
+		return nil, err
 	}
-	return result
+	slices.Sort(result)
+	if descending {
+		slices.Reverse(result)
+	}
+	return result, nil
 }
 
 // Union adds all the elements from the other Set argument into the receiver Set.
-func (s *Set) Union(other *Set) {
-	s.Lock()
-	defer s.Unlock()
-	for _, item := range other.Slice() {
-		s.Insert(item)
+// When both Sets use the same Redis client this is a single atomic
+// SUNIONSTORE. Otherwise the other Set's members are fetched and inserted in
+// batches, which is neither atomic nor cheap for large Sets.
+func (s *Set) Union(other *Set) error {
+	return s.UnionCtx(context.Background(), other)
+}
+
+// UnionCtx is like Union but uses ctx for every Redis command and stops early
+// once ctx is done.
+func (s *Set) UnionCtx(ctx context.Context, other *Set) error {
+	if err := checkSets([]*Set{other}); err != nil {
+		return s.fail(fmt.Errorf("computing union into %s: %w", s.key, err))
 	}
+	if s.serverSide(other) {
+		if err := s.storeOthers(ctx, "union", redis.Cmdable.SUnionStore, []*Set{other}); !isCrossSlot(err) {
+			return err
+		}
+	}
+
+	// Read the other Set before locking the receiver, which may be the same Set.
+	members, err := other.SliceCtx(ctx)
+	if err != nil {
+		return err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	release, err := s.acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+	_, err = s.addMembers(ctx, members)
+	return err
+}
+
+// Len returns the number of elements in the receiver Set. A cardinality that
+// does not fit in an int (only possible on 32-bit platforms) is reported as an
+// error rather than truncated.
+func (s *Set) Len() (int, error) {
+	return s.LenCtx(context.Background())
+}
+
+// LenCtx is like Len but uses ctx for the Redis command.
+func (s *Set) LenCtx(ctx context.Context) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.card(ctx)
+}
+
+// IsEmpty reports whether the Set has no members, using SCARD so that no
+// members are transferred. A key that does not exist is an empty Set. Unlike
+// comparing Len with zero, a failure is never mistaken for emptiness.
+func (s *Set) IsEmpty() (bool, error) {
+	return s.IsEmptyCtx(context.Background())
+}
+
+// IsEmptyCtx is like IsEmpty but uses ctx for the Redis command.
+func (s *Set) IsEmptyCtx(ctx context.Context) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	n, err := s.card(ctx)
+	if err != nil {
+		return false, err
+	}
+	return n == 0, nil
 }
 
-// Len returns the number of elements in the receiver Set.
-func (s *Set) Len() int {
-	s.Lock()
-	defer s.Unlock()
+// Exists reports whether the Set's key exists in Redis. It is false before the
+// first insert and after Clear, Destroy or expiry. Redis deletes a set's key
+// along with its last member, so for a key holding a set Exists is the
+// negation of IsEmpty; unlike IsEmpty it also reports true for a key holding
+// another type.
+func (s *Set) Exists() (bool, error) {
+	return s.ExistsCtx(context.Background())
+}
+
+// ExistsCtx is like Exists but uses ctx for the Redis command.
+func (s *Set) ExistsCtx(ctx context.Context) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
 
-	result, err := s.redisClient.SCard(context.Background(), s.key).Result()
+	n, err := s.redisClient.Exists(ctx, s.key).Result()
+	if err != nil {
+		s.logger.Printf("Error checking existence of %s: %v", s.key, err)
+		return false, s.fail(fmt.Errorf("checking existence of %s: %w", s.key, classify(err)))
+	}
+	return n > 0, nil
+}
+
+// card returns the Set's cardinality. The caller must hold the lock.
+func (s *Set) card(ctx context.Context) (int, error) {
+	var result int64
+	err := s.attempt(ctx, func(ctx context.Context) (err error) {
+		result, err = s.redisClient.SCard(ctx, s.key).Result()
+		return err
+	})
 	if err != nil {
 		s.logger.Printf("Error getting length of %s: %v", s.key, err)
-		return 0
+		return 0, s.fail(fmt.Errorf("getting length of %s: %w", s.key, classify(err)))
 	}
-	return int(result)
+	if result > math.MaxInt {
+		return 0, s.fail(fmt.Errorf("length of %s overflows int: %d", s.key, result))
+	}
+	return int(result), nil
 }
 
 // Subtract removes all elements in the other Set argument from the receiver Set.
-func (s *Set) Subtract(other *Set) {
-	s.Lock()
-	defer s.Unlock()
-	for _, item := range other.Slice() {
-		s.Remove(item)
+// When both Sets use the same Redis client this is a single atomic
+// SDIFFSTORE. Otherwise the other Set's members are fetched and removed in
+// batches, which is neither atomic nor cheap for large Sets.
+func (s *Set) Subtract(other *Set) error {
+	return s.SubtractCtx(context.Background(), other)
+}
+
+// SubtractCtx is like Subtract but uses ctx for every Redis command and stops
+// early once ctx is done.
+func (s *Set) SubtractCtx(ctx context.Context, other *Set) error {
+	if err := checkSets([]*Set{other}); err != nil {
+		return s.fail(fmt.Errorf("computing difference into %s: %w", s.key, err))
+	}
+	if s.serverSide(other) {
+		if err := s.storeOthers(ctx, "difference", redis.Cmdable.SDiffStore, []*Set{other}); !isCrossSlot(err) {
+			return err
+		}
+	}
+
+	// Read the other Set before locking the receiver, which may be the same Set.
+	members, err := other.SliceCtx(ctx)
+	if err != nil {
+		return err
 	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	release, err := s.acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+	_, err = s.removeMembers(ctx, members)
+	return err
 }
 
 // Intersect causes the receiver Set to only contain elements also found in the
-// other Set argument.
-func (s *Set) Intersect(other *Set) {
-	s.Lock()
-	defer s.Unlock()
+// other Set argument. When both Sets use the same Redis client this is a
+// single atomic SINTERSTORE. Otherwise the other Set's members are fetched and
+// the receiver's missing members removed in batches, which is not atomic with
+// respect to other clients.
+func (s *Set) Intersect(other *Set) error {
+	return s.IntersectCtx(context.Background(), other)
+}
+
+// IntersectCtx is like Intersect but uses ctx for every Redis command and
+// stops early once ctx is done. Members already removed stay removed.
+func (s *Set) IntersectCtx(ctx context.Context, other *Set) error {
+	if err := checkSets([]*Set{other}); err != nil {
+		return s.fail(fmt.Errorf("computing intersection into %s: %w", s.key, err))
+	}
+	if s.serverSide(other) {
+		if err := s.storeOthers(ctx, "intersection", redis.Cmdable.SInterStore, []*Set{other}); !isCrossSlot(err) {
+			return err
+		}
+	}
+
+	// Snapshot the other Set before locking the receiver, which may be the
+	// same Set, instead of checking it member by member under the lock.
+	others, err := other.SliceCtx(ctx)
+	if err != nil {
+		return err
+	}
+	keep := make(map[string]nothing, len(others))
+	for _, item := range others {
+		keep[item] = nothing{}
+	}
 
-	members := s.Slice()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	release, err := s.acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	members, err := s.members(ctx)
+	if err != nil {
+		return err
+	}
+	var drop []string
 	for _, item := range members {
-		if !other.Has(item) {
-			s.Remove(item)
+		if _, ok := keep[item]; !ok {
+			drop = append(drop, item)
+		}
+	}
+	_, err = s.removeMembers(ctx, drop)
+	return err
+}
+
+// Err returns the first error encountered by an operation on the receiver Set
+// since it was created or since the last call to ResetErr, much like
+// bufio.Scanner. It lets callers check a sequence of operations once at the end.
+func (s *Set) Err() error {
+	s.errMu.Lock()
+	defer s.errMu.Unlock()
+	return s.err
+}
+
+// ResetErr clears the error reported by Err.
+func (s *Set) ResetErr() {
+	s.errMu.Lock()
+	defer s.errMu.Unlock()
+	s.err = nil
+}
+
+// Key returns the Redis key backing the Set, as used by every operation. It
+// reflects any Rename.
+func (s *Set) Key() string {
+	return s.lockedKey()
+}
+
+// Client returns the Redis client the Set was created with, for running
+// commands the Set does not wrap against Key.
+func (s *Set) Client() redis.Cmdable {
+	return s.redisClient
+}
+
+// doer is implemented by the clients able to send arbitrary commands, such as
+// *redis.Client, *redis.ClusterClient and *redis.Ring.
+type doer interface {
+	Do(ctx context.Context, args ...interface{}) *redis.Cmd
+}
+
+// errNoDo reports that the Set's client cannot send arbitrary commands.
+var errNoDo = errors.New("client cannot send arbitrary commands")
+
+// do sends a command redis.Cmdable has no method for. With a client lacking Do
+// it fails with errNoDo, which isUnknownCommand treats like an unknown command
+// so that callers fall back.
+func (s *Set) do(ctx context.Context, args ...interface{}) *redis.Cmd {
+	if c, ok := s.redisClient.(doer); ok {
+		return c.Do(ctx, args...)
+	}
+	cmd := redis.NewCmd(ctx, args...)
+	cmd.SetErr(errNoDo)
+	return cmd
+}
+
+// isNilClient reports whether c is nil or a nil pointer.
+func isNilClient(c redis.Cmdable) bool {
+	if c == nil {
+		return true
+	}
+	v := reflect.ValueOf(c)
+	return v.Kind() == reflect.Pointer && v.IsNil()
+}
+
+// hasMember checks an already normalized member. The caller must hold the lock.
+func (s *Set) hasMember(ctx context.Context, member string) (bool, error) {
+	if insert, ok := s.buffer.lookup(member); ok {
+		return insert, nil
+	}
+	cached, ok, generation := s.cache.get(member, s.now())
+	if ok {
+		return cached, nil
+	}
+	pending := s.fallback.has(member)
+	var result bool
+	err := s.attempt(ctx, func(ctx context.Context) (err error) {
+		result, err = s.redisClient.SIsMember(ctx, s.key, member).Result()
+		return err
+	})
+	if err != nil {
+		s.logger.Printf("Error checking membership for %s: %v", member, err)
+		err = s.fail(fmt.Errorf("checking membership for %s in %s: %w", member, s.key, classify(err)))
+		if s.degrade(err) {
+			return s.fallback.has(member), nil
+		}
+		return false, err
+	}
+	if pending {
+		result = true
+	}
+	s.cache.put(member, result, generation, s.now())
+	return result, nil
+}
+
+// insertable normalizes element for insertion. It reports false for elements
+// that must be skipped, with ErrEmptyElement if RejectEmpty refuses them.
+func (s *Set) insertable(element string) (string, bool, error) {
+	member, ok := s.normalize(element)
+	if !ok && s.rejectEmpty {
+		return "", false, s.fail(fmt.Errorf("inserting into %s: %w", s.key, ErrEmptyElement))
+	}
+	return member, ok, nil
+}
+
+// insertMember adds an already normalized member and reports whether it was
+// not present before. The caller must hold the lock.
+func (s *Set) insertMember(ctx context.Context, member string) (bool, error) {
+	defer s.cache.forget(member)
+	var added int64
+	err := s.attempt(ctx, func(ctx context.Context) (err error) {
+		added, err = s.write(ctx, func(c redis.Cmdable) *redis.IntCmd {
+			return c.SAdd(ctx, s.key, member)
+		}).Result()
+		return err
+	})
+	if err != nil {
+		s.logger.Printf("Error inserting %s into %s: %v", member, s.key, err)
+		err = s.fail(fmt.Errorf("inserting %s into %s: %w", member, s.key, classify(err)))
+		if s.degrade(err, member) {
+			return s.fallback.has(member), nil
+		}
+		return false, err
+	}
+	return added > 0, nil
+}
+
+// addMembers adds already normalized members in SADD commands of at most
+// batchSize members and returns how many were not present before. The caller
+// must hold the lock.
+func (s *Set) addMembers(ctx context.Context, members []string) (int64, error) {
+	return s.inBatches(ctx, "inserting members into", redis.Cmdable.SAdd, members)
+}
+
+// removeMembers deletes already normalized members in SREM commands of at most
+// batchSize members and returns how many were present. The caller must hold
+// the lock.
+func (s *Set) removeMembers(ctx context.Context, members []string) (int64, error) {
+	s.fallback.forget(members...)
+	return s.inBatches(ctx, "removing members from", redis.Cmdable.SRem, members)
+}
+
+// inBatches sends members to cmd in chunks of batchSize, checking ctx between
+// chunks, and sums the replies. A failure is reported as a *BatchError. The
+// caller must hold the lock.
+func (s *Set) inBatches(ctx context.Context, op string, cmd membersCmd, members []string) (int64, error) {
+	defer s.cache.flush()
+	var total int64
+	for start := 0; start < len(members); start += s.batchSize {
+		chunk := members[start:min(start+s.batchSize, len(members))]
+		err := ctx.Err()
+		if err == nil {
+			var n int64
+			n, err = s.batch(ctx, cmd, chunk)
+			total += n
+		}
+		if err != nil {
+			s.logger.Printf("Error %s %s after %d members: %v", op, s.key, start, err)
+			return total, s.fail(&BatchError{
+				Processed: start,
+				Err:       fmt.Errorf("%s %s: %w", op, s.key, classify(err)),
+			})
+		}
+	}
+	return total, nil
+}
+
+// insertPipelined normalizes and adds elements in SADD commands of at most
+// batchSize members, sending pipelineDepth commands per round trip. Only one
+// round trip's worth of members is held in memory at a time. A failure is
+// reported as a *BatchError whose Processed counts members from earlier round
+// trips. The returned stats count the members SADD reported as added. The
+// caller must hold the lock.
+func (s *Set) insertPipelined(ctx context.Context, elements []string) (DeduplicateStats, error) {
+	defer s.cache.flush()
+	var (
+		pipe      = s.redisClient.Pipeline()
+		cmds      = make([]*redis.IntCmd, 0, pipelineDepth)
+		chunk     = make([]string, 0, min(s.batchSize, len(elements)))
+		queued    int
+		processed int
+		stats     = DeduplicateStats{Input: len(elements)}
+	)
+	queue := func() {
+		if len(chunk) > 0 {
+			cmds = append(cmds, pipe.SAdd(ctx, s.key, toArgs(chunk)...))
+			queued += len(chunk)
+			chunk = chunk[:0]
+		}
+	}
+	flush := func() error {
+		if queued == 0 {
+			return nil
+		}
+		err := ctx.Err()
+		if err == nil {
+			err = s.execPipeline(ctx, pipe)
+		}
+		if err != nil {
+			s.logger.Printf("Error inserting members into %s after %d members: %v", s.key, processed, err)
+			return s.fail(&BatchError{
+				Processed: processed,
+				Err:       fmt.Errorf("inserting members into %s: %w", s.key, classify(err)),
+			})
+		}
+		for _, cmd := range cmds {
+			stats.Unique += int(cmd.Val())
+		}
+		cmds = cmds[:0]
+		processed += queued
+		queued = 0
+		return nil
+	}
+
+	for _, element := range elements {
+		member, ok, err := s.insertable(element)
+		if err != nil {
+			pipe.Discard()
+			return DeduplicateStats{}, err
+		}
+		if !ok {
+			stats.Skipped++
+			continue
+		}
+		if chunk = append(chunk, member); len(chunk) < s.batchSize {
+			continue
+		}
+		queue()
+		if queued >= pipelineDepth*s.batchSize {
+			if err := flush(); err != nil {
+				return DeduplicateStats{}, err
+			}
+		}
+	}
+	queue()
+	if err := flush(); err != nil {
+		return DeduplicateStats{}, err
+	}
+	stats.Duplicates = stats.Input - stats.Skipped - stats.Unique
+	return stats, nil
+}
+
+// insertEach adds elements and calls added, in input order, for each element
+// whose member was not present before. Each batch of up to batchSize elements
+// is sent as pipelined single-member SADDs, so one round trip tells which
+// members were new. A failure is reported as a *BatchError. The caller must
+// hold the lock.
+func (s *Set) insertEach(ctx context.Context, elements []string, added func(element, member string)) error {
+	processed := 0
+	for start := 0; start < len(elements); start += s.batchSize {
+		n, err := s.insertBatch(ctx, elements[start:min(start+s.batchSize, len(elements))], processed, added)
+		if err != nil {
+			return err
+		}
+		processed += n
+	}
+	return nil
+}
+
+// insertBatch is insertEach for a single batch, returning the number of
+// members sent. processed is only used to report a failure. The caller must
+// hold the lock.
+func (s *Set) insertBatch(ctx context.Context, elements []string, processed int, added func(element, member string)) (int, error) {
+	defer s.cache.flush()
+	type pending struct {
+		element, member string
+		cmd             *redis.IntCmd
+	}
+	pipe := s.redisClient.Pipeline()
+	batch := make([]pending, 0, len(elements))
+	seen := make(map[string]nothing, len(elements))
+	for _, element := range elements {
+		member, ok, err := s.insertable(element)
+		if err != nil {
+			pipe.Discard()
+			return 0, err
+		}
+		if _, dup := seen[member]; !ok || dup {
+			continue
+		}
+		seen[member] = nothing{}
+		batch = append(batch, pending{element, member, pipe.SAdd(ctx, s.key, member)})
+	}
+	if len(batch) == 0 {
+		return 0, nil
+	}
+
+	err := ctx.Err()
+	if err == nil {
+		err = s.execPipeline(ctx, pipe)
+	}
+	if err != nil {
+		s.logger.Printf("Error inserting members into %s after %d members: %v", s.key, processed, err)
+		return 0, s.fail(&BatchError{
+			Processed: processed,
+			Err:       fmt.Errorf("inserting members into %s: %w", s.key, classify(err)),
+		})
+	}
+	for _, p := range batch {
+		if p.cmd.Val() > 0 {
+			added(p.element, p.member)
+		}
+	}
+	return len(batch), nil
+}
+
+func (s *Set) execPipeline(ctx context.Context, pipe redis.Pipeliner) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+	s.touch(ctx, pipe)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func (s *Set) batch(ctx context.Context, cmd membersCmd, chunk []string) (int64, error) {
+	var n int64
+	err := s.attempt(ctx, func(ctx context.Context) (err error) {
+		n, err = s.write(ctx, func(c redis.Cmdable) *redis.IntCmd {
+			return cmd(c, ctx, s.key, toArgs(chunk)...)
+		}).Result()
+		return err
+	})
+	return n, err
+}
+
+// write runs cmd, a command modifying the Set's key, against the Set's client.
+// With WithIdleTTL the PEXPIRE refreshing the key's TTL is pipelined with it,
+// so the refresh costs no extra round trip.
+func (s *Set) write(ctx context.Context, cmd func(c redis.Cmdable) *redis.IntCmd) *redis.IntCmd {
+	return written(ctx, s, cmd)
+}
+
+// written is write for commands of any reply type.
+func written[C redis.Cmder](ctx context.Context, s *Set, cmd func(c redis.Cmdable) C) C {
+	if s.idleTTL <= 0 {
+		return cmd(s.redisClient)
+	}
+	var result C
+	// Failures are reported through the commands themselves.
+	_, _ = s.redisClient.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		result = cmd(pipe)
+		s.touch(ctx, pipe)
+		return nil
+	})
+	return result
+}
+
+// touch queues the PEXPIRE of WithIdleTTL on pipe, if the option is set.
+func (s *Set) touch(ctx context.Context, pipe redis.Pipeliner) {
+	s.touchKey(ctx, pipe, s.key)
+}
+
+// touchKey is touch for key, the Set's key as read by a caller that must not
+// hold the Set's lock.
+func (s *Set) touchKey(ctx context.Context, pipe redis.Pipeliner, key string) {
+	if s.idleTTL > 0 {
+		pipe.PExpire(ctx, key, s.idleTTL)
+	}
+}
+
+// members retrieves every member of the Set, with SMEMBERS or, for Sets above
+// the scan threshold, SSCAN. The caller must hold the lock.
+func (s *Set) members(ctx context.Context) ([]string, error) {
+	if s.scanThreshold == 0 {
+		return s.scanMembers(ctx, 0)
+	}
+	if s.scanThreshold > 0 {
+		n, err := s.card(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if n > s.scanThreshold {
+			return s.scanMembers(ctx, n)
+		}
+	}
+
+	var result []string
+	err := s.attempt(ctx, func(ctx context.Context) (err error) {
+		result, err = s.redisClient.SMembers(ctx, s.key).Result()
+		return err
+	})
+	if err != nil {
+		s.logger.Printf("Error retrieving members for %s: %v", s.key, err)
+		return nil, s.fail(fmt.Errorf("retrieving members for %s: %w", s.key, classify(err)))
+	}
+	if result == nil {
+		result = []string{}
+	}
+	return result, nil
+}
+
+// lockedKey returns the Set's current key, which Rename may change, for use by
+// an operation on another Set. The caller must not hold s's lock.
+func (s *Set) lockedKey() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.key
+}
+
+// sharesClient reports whether all others use the same Redis client as s, so
+// that commands involving all their keys can run server-side.
+func (s *Set) sharesClient(others ...*Set) bool {
+	for _, other := range others {
+		if other.redisClient != s.redisClient {
+			return false
 		}
 	}
+	return true
+}
+
+// storeOthers runs store over the receiver's key and those of others.
+func (s *Set) storeOthers(ctx context.Context, op string, cmd storeCmd, others []*Set) error {
+	keys := keysOf(others)
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.store(ctx, op, cmd, append([]string{s.key}, keys...)...)
+}
+
+// store runs a set-algebra STORE command writing the combination of keys into
+// the receiver's key. A CROSSSLOT error is returned unwrapped so the caller
+// can fall back. The caller must hold the lock.
+func (s *Set) store(ctx context.Context, op string, cmd storeCmd, keys ...string) error {
+	defer s.cache.flush()
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	err := s.write(ctx, func(c redis.Cmdable) *redis.IntCmd {
+		return cmd(c, ctx, s.key, keys...)
+	}).Err()
+	if s.crossSlot(err) {
+		return err
+	}
+	if err != nil {
+		s.logger.Printf("Error computing %s into %s: %v", op, s.key, err)
+		return s.fail(fmt.Errorf("computing %s into %s: %w", op, s.key, classify(err)))
+	}
+	return nil
+}
+
+// removeMember deletes an already normalized member. The caller must hold the
+// lock.
+func (s *Set) removeMember(ctx context.Context, member string) error {
+	defer s.cache.forget(member)
+	s.fallback.forget(member)
+	err := s.attempt(ctx, func(ctx context.Context) error {
+		return s.write(ctx, func(c redis.Cmdable) *redis.IntCmd {
+			return c.SRem(ctx, s.key, member)
+		}).Err()
+	})
+	if err != nil {
+		s.logger.Printf("Error removing %s from %s: %v", member, s.key, err)
+		return s.fail(fmt.Errorf("removing %s from %s: %w", member, s.key, classify(err)))
+	}
+	return nil
+}
+
+// fail records err for Err, unless an earlier error is already recorded, and
+// returns it.
+func (s *Set) fail(err error) error {
+	s.errMu.Lock()
+	defer s.errMu.Unlock()
+	if s.err == nil {
+		s.err = err
+	}
+	return err
 }
 
-// String implements the flag.Value interface.
+// String implements the flag.Value interface. It returns an empty string for
+// a nil or zero Set, as flag.PrintDefaults may call it on a zero value.
 func (s *Set) String() string {
-	s.Lock()
-	defer s.Unlock()
-	return strings.Join(s.Slice(), ",")
+	if s == nil || s.redisClient == nil {
+		return ""
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	members, err := s.members(context.Background())
+	if err != nil {
+		return ""
+	}
+	return strings.Join(members, ",")
 }
 
-// Set implements the flag.Value interface.
+// Set implements the flag.Value interface. It inserts the comma-separated,
+// trimmed elements of input as InsertMany does, so they are sent in variadic
+// SADDs and honor WithWriteBuffer and WithDegradedMode.
 func (s *Set) Set(input string) error {
-	s.Lock()
-	defer s.Unlock()
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 	if input == "" {
 		return fmt.Errorf("string parsing failed")
 	}
 
+	var members []string
 	for _, item := range strings.Split(input, ",") {
-		s.Insert(strings.TrimSpace(item))
+		item = strings.TrimSpace(item)
+		if item == "" && s.rejectEmpty {
+			continue
+		}
+		member, ok, err := s.insertable(item)
+		if err != nil {
+			return err
+		}
+		if ok {
+			members = append(members, member)
+		}
 	}
-	return nil
+	return s.insertMembers(context.Background(), members)
 }
@@ -0,0 +1,1355 @@
+package redisstringset
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func TestNewWithInitialMembers(t *testing.T) {
+	client, server := newTestClient(t)
+	done := make(chan *Set)
+	go func() { done <- New(client, "seeded", "a", "B", "a") }()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("New with initial members did not return")
+	}
+	got := mustMembers(t, server, "seeded")
+	if want := []string{"a", "b"}; !slices.Equal(got, want) {
+		t.Errorf("members = %v, want %v", got, want)
+	}
+}
+
+// TestConcurrentAccess is meant for go test -race.
+func TestConcurrentAccess(t *testing.T) {
+	s, _ := newTestSet(t, "concurrent")
+	const workers, rounds = 8, 50
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < rounds; i++ {
+				member := fmt.Sprintf("m%d", (w*rounds+i)%17)
+				var err error
+				switch i % 5 {
+				case 0:
+					err = s.Insert(member)
+				case 1:
+					err = s.InsertMany(member, member+"x", member+"y")
+				case 2:
+					_, err = s.Has(member)
+				case 3:
+					err = s.Remove(member)
+				case 4:
+					err = s.Close()
+				}
+				if err != nil {
+					t.Errorf("operation %d on %s: %v", i%5, member, err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if err := s.Err(); err != nil {
+		t.Errorf("Err = %v", err)
+	}
+}
+
+// TestCloseRacesOperations is meant for go test -race.
+func TestCloseRacesOperations(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		opts []Option
+	}{
+		{"plain", nil},
+		{"delete on close", []Option{DeleteOnClose()}},
+		{"write buffer", []Option{WithWriteBuffer(4, time.Millisecond)}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			client, _ := newTestClient(t)
+			s := newClientSet(t, client, "closing", tt.opts...)
+			stop := make(chan struct{})
+			var wg sync.WaitGroup
+			for w := 0; w < 4; w++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for i := 0; ; i++ {
+						select {
+						case <-stop:
+							return
+						default:
+						}
+						err := s.Insert(fmt.Sprintf("m%d", i%10))
+						if err == nil {
+							_, err = s.Slice()
+						}
+						if err != nil && !errors.Is(err, ErrClosed) {
+							t.Errorf("operation racing Close: %v", err)
+							return
+						}
+					}
+				}()
+			}
+			for i := 0; i < 20; i++ {
+				if err := s.Close(); err != nil {
+					t.Errorf("Close: %v", err)
+				}
+			}
+			client.Close()
+			close(stop)
+			wg.Wait()
+
+			err := s.Insert("late")
+			if err == nil {
+				err = s.Flush()
+			}
+			if !errors.Is(err, ErrClosed) {
+				t.Errorf("Insert after closing the client = %v, want ErrClosed", err)
+			}
+			if _, err := s.Slice(); !errors.Is(err, ErrClosed) {
+				t.Errorf("Slice after closing the client = %v, want ErrClosed", err)
+			}
+		})
+	}
+}
+
+func TestOppositeUnionsDoNotDeadlock(t *testing.T) {
+	client, _ := newTestClient(t)
+	separate, _ := newTestClient(t)
+	for _, tt := range []struct {
+		name   string
+		second *Set
+	}{
+		{"same client", newClientSet(t, client, "right")},
+		{"different clients", newClientSet(t, separate, "right")},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			a, b := newClientSet(t, client, "left"), tt.second
+			a.InsertMany("a", "shared")
+			b.InsertMany("b", "shared")
+
+			done := make(chan struct{})
+			var wg sync.WaitGroup
+			for _, pair := range [][2]*Set{{a, b}, {b, a}} {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for i := 0; i < 100; i++ {
+						if err := pair[0].Union(pair[1]); err != nil {
+							t.Errorf("Union: %v", err)
+							return
+						}
+						if err := pair[0].Intersect(pair[1]); err != nil {
+							t.Errorf("Intersect: %v", err)
+							return
+						}
+					}
+				}()
+			}
+			go func() { wg.Wait(); close(done) }()
+			select {
+			case <-done:
+			case <-time.After(10 * time.Second):
+				t.Fatal("opposite unions deadlocked")
+			}
+		})
+	}
+}
+
+func BenchmarkHas(b *testing.B) {
+	s, _ := newTestSet(b, "bench")
+	s.InsertMany("a", "b", "c")
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := s.Has("b"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkHasParallel measures concurrent Has calls, which share the Set's
+// read lock; compare its ns/op with BenchmarkHas.
+func BenchmarkHasParallel(b *testing.B) {
+	s, _ := newTestSet(b, "bench")
+	s.InsertMany("a", "b", "c")
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if _, err := s.Has("b"); err != nil {
+				b.Error(err)
+				return
+			}
+		}
+	})
+}
+
+func TestDeduplicateWithStats(t *testing.T) {
+	tests := []struct {
+		name  string
+		input []string
+		opts  []Option
+		want  DeduplicateStats
+	}{
+		{"empty", nil, nil, DeduplicateStats{}},
+		{"all unique", []string{"a", "b", "c", "d"}, nil, DeduplicateStats{Input: 4, Unique: 4}},
+		{"half duplicates", []string{"a", "A", "b", "B", "c", "C"}, nil, DeduplicateStats{Input: 6, Unique: 3, Duplicates: 3}},
+		{"skipped", []string{" a", "a ", "  ", ""}, []Option{WithTrimSpace()}, DeduplicateStats{Input: 4, Skipped: 2, Unique: 1, Duplicates: 1}},
+		{"across batches", slices.Repeat([]string{"x", "y", "z", "w"}, 25), []Option{WithBatchSize(3)}, DeduplicateStats{Input: 100, Unique: 4, Duplicates: 96}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, server := newTestClient(t)
+			result, stats, err := DeduplicateWithStats(client, "", tt.input, append(tt.opts, WithLogger(nil))...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if stats != tt.want {
+				t.Errorf("stats = %+v, want %+v", stats, tt.want)
+			}
+			if len(result) != tt.want.Unique {
+				t.Errorf("got %d members, want %d", len(result), tt.want.Unique)
+			}
+			if keys := server.Keys(); len(keys) != 0 {
+				t.Errorf("keys left behind: %v", keys)
+			}
+		})
+	}
+}
+
+func TestDeduplicateIntoExistingKey(t *testing.T) {
+	client, server := newTestClient(t)
+	server.SAdd("taken", "old")
+	_, _, err := DeduplicateWithStats(client, "taken", []string{"old", "new"}, WithLogger(nil))
+	if !errors.Is(err, ErrKeyExists) {
+		t.Errorf("DeduplicateWithStats into an existing key = %v, want ErrKeyExists", err)
+	}
+	if got := mustMembers(t, server, "taken"); !slices.Equal(got, []string{"old"}) {
+		t.Errorf("existing key = %v, want it untouched", got)
+	}
+
+	result, stats, err := DeduplicateWithStats(client, "free", []string{"old", "new", "old"}, WithLogger(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (DeduplicateStats{Input: 3, Unique: 2, Duplicates: 1}); stats != want || len(result) != 2 {
+		t.Errorf("into a free key: %v, %+v, want 2 members and %+v", result, stats, want)
+	}
+}
+
+func TestSliceRetrieval(t *testing.T) {
+	members := make([]string, 30)
+	for i := range members {
+		members[i] = fmt.Sprintf("m%02d", i)
+	}
+	tests := []struct {
+		name                   string
+		opts                   []Option
+		scard, smembers, sscan bool
+	}{
+		{"default", nil, false, true, false},
+		{"below threshold", []Option{WithScanThreshold(100)}, true, true, false},
+		{"above threshold", []Option{WithScanThreshold(10), WithScanCount(7)}, true, false, true},
+		{"always scan", []Option{WithScanThreshold(0), WithScanCount(7)}, false, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, f, _ := newFaultyClient(t)
+			s := newClientSet(t, client, "sliced", tt.opts...)
+			s.InsertMany(members...)
+
+			got, err := s.Slice()
+			if err != nil {
+				t.Fatal(err)
+			}
+			slices.Sort(got)
+			if !slices.Equal(got, members) {
+				t.Errorf("Slice = %v, want %v", got, members)
+			}
+			for cmd, want := range map[string]bool{"scard": tt.scard, "smembers": tt.smembers, "sscan": tt.sscan} {
+				if sent := f.count(cmd) > 0; sent != want {
+					t.Errorf("sent %s: %v, want %v", cmd, sent, want)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkSliceLarge compares SMEMBERS with SSCAN assembly on a large Set.
+// SSCAN costs more in total, but none of its commands holds the server for
+// longer than a page takes; miniredis, whose SSCAN sorts the whole set for
+// every page, exaggerates the total.
+func BenchmarkSliceLarge(b *testing.B) {
+	members := make([]string, 20000)
+	for i := range members {
+		members[i] = fmt.Sprintf("member-%d", i)
+	}
+	for _, bb := range []struct {
+		name string
+		opt  Option
+	}{
+		{"SMEMBERS", WithScanThreshold(-1)},
+		{"SSCAN", WithScanThreshold(0)},
+	} {
+		b.Run(bb.name, func(b *testing.B) {
+			s, _ := newTestSet(b, "large", bb.opt)
+			if err := s.InsertMany(members...); err != nil {
+				b.Fatal(err)
+			}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := s.Slice(); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestInsertReportsFailure(t *testing.T) {
+	s := newDeadSet(t, "dead")
+	err := s.Insert("Element")
+	if !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("Insert = %v, want ErrUnavailable", err)
+	}
+	if msg := err.Error(); !strings.Contains(msg, "dead") || !strings.Contains(msg, "element") {
+		t.Errorf("Insert error %q does not name the key and element", msg)
+	}
+	var batchErr *BatchError
+	if err := s.InsertMany("a", "b"); !errors.As(err, &batchErr) || !errors.Is(err, ErrUnavailable) {
+		t.Errorf("InsertMany = %v, want a *BatchError wrapping ErrUnavailable", err)
+	}
+
+	// New has no error to return, but records seeding failures for Err.
+	client, server := newTestClient(t)
+	server.Close()
+	seeded := New(client, "seeded", "a")
+	if !errors.Is(seeded.Err(), ErrUnavailable) {
+		t.Errorf("Err after seeding a dead server = %v, want ErrUnavailable", seeded.Err())
+	}
+}
+
+func TestHasReportsFailure(t *testing.T) {
+	s, _ := newTestSet(t, "live")
+	s.Insert("a")
+	if ok, err := s.Has("A"); !ok || err != nil {
+		t.Errorf("Has(A) = %v, %v, want true", ok, err)
+	}
+	if ok, err := s.Has("b"); ok || err != nil {
+		t.Errorf("Has(b) = %v, %v, want false and no error", ok, err)
+	}
+
+	dead := newDeadSet(t, "dead")
+	ok, err := dead.Has("b")
+	if ok || !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("Has on a dead server = %v, %v, want ErrUnavailable", ok, err)
+	}
+	if !strings.Contains(err.Error(), "dead") {
+		t.Errorf("Has error %q does not name the key", err)
+	}
+}
+
+func TestSliceReportsFailure(t *testing.T) {
+	s, _ := newTestSet(t, "empty")
+	if got, err := s.Slice(); got == nil || len(got) != 0 || err != nil {
+		t.Errorf("Slice of an empty Set = %#v, %v, want a non-nil empty slice", got, err)
+	}
+
+	dead := newDeadSet(t, "dead")
+	if got, err := dead.Slice(); got != nil || !errors.Is(err, ErrUnavailable) {
+		t.Errorf("Slice on a dead server = %#v, %v, want nil and ErrUnavailable", got, err)
+	}
+}
+
+// countHook answers every command named cmd, such as "scard", with n without
+// sending it.
+type countHook struct {
+	cmd string
+	n   int64
+}
+
+func (h countHook) DialHook(next redis.DialHook) redis.DialHook { return next }
+
+func (h countHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		if c, ok := cmd.(*redis.IntCmd); ok && cmd.Name() == h.cmd {
+			c.SetVal(h.n)
+			return nil
+		}
+		return next(ctx, cmd)
+	}
+}
+
+func (h countHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return next
+}
+
+func TestLenReportsFailure(t *testing.T) {
+	s, _ := newTestSet(t, "counted")
+	s.InsertMany("a", "b", "c")
+	if n, err := s.Len(); n != 3 || err != nil {
+		t.Errorf("Len = %d, %v, want 3", n, err)
+	}
+
+	dead := newDeadSet(t, "dead")
+	if n, err := dead.Len(); n != 0 || !errors.Is(err, ErrUnavailable) {
+		t.Errorf("Len on a dead server = %d, %v, want ErrUnavailable", n, err)
+	}
+
+	// A cardinality beyond 32 bits is returned whole, or refused where int
+	// cannot hold it, never truncated.
+	client, _ := newTestClient(t)
+	client.AddHook(countHook{"scard", 1 << 40})
+	large := newClientSet(t, client, "large")
+	n, err := large.Len()
+	if math.MaxInt > 1<<40 {
+		if int64(n) != 1<<40 || err != nil {
+			t.Errorf("Len = %d, %v, want %d", n, err, int64(1<<40))
+		}
+	} else if err == nil {
+		t.Errorf("Len = %d, want an overflow error", n)
+	}
+}
+
+func TestRemoveReportsFailure(t *testing.T) {
+	s, server := newTestSet(t, "live")
+	s.InsertMany("a", "b")
+	if err := s.Remove("A"); err != nil {
+		t.Fatal(err)
+	}
+	if got := mustMembers(t, server, "live"); !slices.Equal(got, []string{"b"}) {
+		t.Errorf("members after Remove = %v, want [b]", got)
+	}
+
+	dead := newDeadSet(t, "dead")
+	err := dead.Remove("Token")
+	if !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("Remove on a dead server = %v, want ErrUnavailable", err)
+	}
+	if msg := err.Error(); !strings.Contains(msg, "dead") || !strings.Contains(msg, "token") {
+		t.Errorf("Remove error %q does not name the key and element", msg)
+	}
+}
+
+func TestCloseTwice(t *testing.T) {
+	logs := &logRecorder{}
+	client, f, server := newFaultyClient(t)
+	s := newClientSet(t, client, "scratch", DeleteOnClose(), WithPrintfLogger(logs))
+	s.Insert("a")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := s.Close(); err != nil {
+				t.Errorf("Close: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if err := s.Close(); err != nil {
+		t.Errorf("Close after Close: %v", err)
+	}
+	if n := f.count("unlink"); n != 1 {
+		t.Errorf("sent unlink %d times, want 1", n)
+	}
+	if server.Exists("scratch") {
+		t.Error("key survived Close")
+	}
+	if got := logs.messages(); len(got) != 0 {
+		t.Errorf("logged %q", got)
+	}
+}
+
+func TestCloseReportsFailure(t *testing.T) {
+	logs := &logRecorder{}
+	dead := newDeadSet(t, "dead", DeleteOnClose(), WithPrintfLogger(logs))
+	if err := dead.Close(); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("Close on a dead server = %v, want ErrUnavailable", err)
+	}
+	if got := logs.messages(); len(got) != 1 || !strings.Contains(got[0], "dead") {
+		t.Errorf("logged %q, want one message naming the key", got)
+	}
+
+	// Without DeleteOnClose, Close sends nothing and cannot fail.
+	if err := newDeadSet(t, "kept").Close(); err != nil {
+		t.Errorf("Close on a dead server without DeleteOnClose = %v", err)
+	}
+}
+
+func TestNewE(t *testing.T) {
+	client, server := newTestClient(t)
+	s, err := NewE(client, "seeded", "a", "B")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := mustMembers(t, server, s.Key()); !slices.Equal(got, []string{"a", "b"}) {
+		t.Errorf("members = %v, want [a b]", got)
+	}
+
+	var nilClient *redis.Client
+	for name, c := range map[string]redis.Cmdable{"nil": nil, "nil pointer": nilClient} {
+		if s, err := NewE(c, "key"); s != nil || err == nil {
+			t.Errorf("NewE with a %s client = %v, %v, want an error", name, s, err)
+		}
+	}
+
+	dead, server := newTestClient(t)
+	server.Close()
+	if s, err := NewE(dead, "key", "a"); s != nil || !errors.Is(err, ErrUnavailable) {
+		t.Errorf("NewE on an unreachable server = %v, %v, want ErrUnavailable", s, err)
+	}
+}
+
+func TestDeduplicate(t *testing.T) {
+	client, server := newTestClient(t)
+	got, err := Deduplicate(client, "", []string{"b", "A", "a", "c", "B"}, WithLogger(nil))
+	slices.Sort(got)
+	if want := []string{"a", "b", "c"}; !slices.Equal(got, want) || err != nil {
+		t.Errorf("Deduplicate = %v, %v, want %v", got, err, want)
+	}
+	if got, err := Deduplicate(client, "", nil, WithLogger(nil)); got == nil || len(got) != 0 || err != nil {
+		t.Errorf("Deduplicate(nil) = %#v, %v, want an empty slice", got, err)
+	}
+	if keys := server.Keys(); len(keys) != 0 {
+		t.Errorf("keys left behind: %v", keys)
+	}
+}
+
+func TestDeduplicateFailures(t *testing.T) {
+	for _, cmd := range []string{"sadd", "smembers"} {
+		t.Run(cmd, func(t *testing.T) {
+			client, f, server := newFaultyClient(t)
+			f.inject(cmd, -1, errRefused)
+			got, err := Deduplicate(client, "scratch", []string{"a", "b", "a"}, WithLogger(nil))
+			if got != nil || !errors.Is(err, ErrUnavailable) {
+				t.Errorf("Deduplicate = %v, %v, want ErrUnavailable", got, err)
+			}
+			if server.Exists("scratch") {
+				t.Error("temporary key survived the failure")
+			}
+			if f.count("unlink") != 1 {
+				t.Error("temporary key was not deleted")
+			}
+		})
+	}
+}
+
+func TestErrIsSticky(t *testing.T) {
+	client, f, _ := newFaultyClient(t)
+	s := newClientSet(t, client, "sticky")
+	if err := s.Err(); err != nil {
+		t.Fatalf("Err on a new Set = %v", err)
+	}
+
+	f.inject("sadd", -1, errRefused)
+	f.inject("sismember", -1, errWrongType)
+	first := s.Insert("a")
+	s.Has("a")
+	s.Insert("b")
+	if err := s.Err(); err != first || !errors.Is(err, ErrUnavailable) {
+		t.Errorf("Err = %v, want the first failure %v", err, first)
+	}
+
+	// Successes neither clear nor replace the recorded error.
+	f.heal()
+	if err := s.Insert("c"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Err(); err != first {
+		t.Errorf("Err after a success = %v, want %v", err, first)
+	}
+
+	s.ResetErr()
+	if err := s.Err(); err != nil {
+		t.Errorf("Err after ResetErr = %v", err)
+	}
+	f.inject("sismember", 1, errWrongType)
+	s.Has("a")
+	if err := s.Err(); !errors.Is(err, ErrWrongType) {
+		t.Errorf("Err after ResetErr and a failure = %v, want ErrWrongType", err)
+	}
+}
+
+func TestDoneContext(t *testing.T) {
+	ops := []struct {
+		name string
+		run  func(ctx context.Context, s, other *Set) error
+	}{
+		{"InsertCtx", func(ctx context.Context, s, _ *Set) error { return s.InsertCtx(ctx, "c") }},
+		{"InsertManyCtx", func(ctx context.Context, s, _ *Set) error { return s.InsertManyCtx(ctx, "c", "d") }},
+		{"HasCtx", func(ctx context.Context, s, _ *Set) error {
+			_, err := s.HasCtx(ctx, "a")
+			return err
+		}},
+		{"RemoveCtx", func(ctx context.Context, s, _ *Set) error { return s.RemoveCtx(ctx, "a") }},
+		{"SliceCtx", func(ctx context.Context, s, _ *Set) error {
+			_, err := s.SliceCtx(ctx)
+			return err
+		}},
+		{"LenCtx", func(ctx context.Context, s, _ *Set) error {
+			_, err := s.LenCtx(ctx)
+			return err
+		}},
+		{"IntersectCtx", func(ctx context.Context, s, other *Set) error { return s.IntersectCtx(ctx, other) }},
+	}
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	expired, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+	for _, op := range ops {
+		t.Run(op.name, func(t *testing.T) {
+			client, server := newTestClient(t)
+			separate, _ := newTestClient(t)
+			s := newClientSet(t, client, "done")
+			other := newClientSet(t, separate, "done:other")
+			s.InsertMany("a", "b")
+
+			err := op.run(cancelled, s, other)
+			if !errors.Is(err, context.Canceled) || !strings.Contains(err.Error(), "done") {
+				t.Errorf("with a cancelled context = %v, want context.Canceled wrapped with the key", err)
+			}
+			err = op.run(expired, s, other)
+			if !errors.Is(err, ErrTimeout) || !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("with an expired context = %v, want ErrTimeout wrapping context.DeadlineExceeded", err)
+			}
+			if got := mustMembers(t, server, "done"); !slices.Equal(got, []string{"a", "b"}) {
+				t.Errorf("members = %v, want them untouched", got)
+			}
+		})
+	}
+}
+
+func TestDeduplicateKeepCase(t *testing.T) {
+	tests := []struct {
+		name  string
+		input []string
+		opts  []Option
+		want  []string
+	}{
+		{"first spelling wins", []string{"Foo", "foo", "FOO"}, nil, []string{"Foo"}},
+		{"first occurrence keeps its position", []string{"b", "Apple", "B", "apple", "Cherry", "APPLE"}, nil, []string{"b", "Apple", "Cherry"}},
+		{"across batches", []string{"X", "y", "x", "Z", "Y", "z", "w"}, []Option{WithBatchSize(2)}, []string{"X", "y", "Z", "w"}},
+		{"original untrimmed", []string{" Pad ", "pad"}, []Option{WithTrimSpace()}, []string{" Pad "}},
+		{"empty", nil, nil, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, server := newTestClient(t)
+			got, err := DeduplicateKeepCase(client, "", tt.input, append(tt.opts, WithLogger(nil))...)
+			if !slices.Equal(got, tt.want) || got == nil || err != nil {
+				t.Errorf("DeduplicateKeepCase = %q, %v, want %q", got, err, tt.want)
+			}
+			if keys := server.Keys(); len(keys) != 0 {
+				t.Errorf("keys left behind: %v", keys)
+			}
+		})
+	}
+}
+
+func TestDeduplicateOrdered(t *testing.T) {
+	client, _ := newTestClient(t)
+	input := []string{"b", "Apple", "B", "apple", "Cherry", "APPLE", "a"}
+	got, err := DeduplicateOrdered(client, "", input, WithLogger(nil))
+	if want := []string{"b", "apple", "cherry", "a"}; !slices.Equal(got, want) || err != nil {
+		t.Errorf("DeduplicateOrdered = %q, %v, want %q", got, err, want)
+	}
+}
+
+func TestDeduplicateOrderedAtScale(t *testing.T) {
+	// Every member appears three times, first in upper case, and as 7919 is
+	// prime to 10000 the first 10000 elements are its first occurrences.
+	input := make([]string, 30000)
+	for i := range input {
+		format := "m-%d"
+		if i < len(input)/3 {
+			format = "M-%d"
+		}
+		input[i] = fmt.Sprintf(format, i*7919%10000)
+	}
+	want := make([]string, 10000)
+	for i := range want {
+		want[i] = strings.ToLower(input[i])
+	}
+	client, server := newTestClient(t)
+	got, err := DeduplicateOrdered(client, "", input, WithLogger(nil), WithBatchSize(1000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("DeduplicateOrdered returned %d members, not the %d first occurrences in order", len(got), len(want))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	hook := &cancelAfter{cancel: cancel}
+	hook.n.Store(2)
+	client.AddHook(hook)
+	got, err = DeduplicateOrderedCtx(ctx, client, "", input, WithLogger(nil), WithBatchSize(1000))
+	var batchErr *BatchError
+	if got != nil || !errors.As(err, &batchErr) || !errors.Is(err, context.Canceled) {
+		t.Fatalf("DeduplicateOrderedCtx cancelled between batches = %d members, %v, want a *BatchError wrapping context.Canceled", len(got), err)
+	}
+	if batchErr.Processed != 2000 {
+		t.Errorf("Processed = %d, want the 2000 members of two batches", batchErr.Processed)
+	}
+	if keys := server.Keys(); len(keys) != 0 {
+		t.Errorf("keys left behind: %v", keys)
+	}
+}
+
+func TestFlagValue(t *testing.T) {
+	client, f, _ := newFaultyClient(t)
+	s := newClientSet(t, client, "names")
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.Var(s, "names", "names to accept")
+	if err := fs.Parse([]string{"-names", "a,B, c"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := s.String(); got != "a,b,c" {
+		t.Errorf("String = %q, want a,b,c", got)
+	}
+	if n := f.count("sadd"); n != 1 {
+		t.Errorf("sent sadd %d times, want one variadic SADD", n)
+	}
+	fs.SetOutput(io.Discard)
+	fs.PrintDefaults()
+	if err := s.Set(""); err == nil {
+		t.Error("Set(\"\") succeeded")
+	}
+
+	var zero Set
+	var nilSet *Set
+	if zero.String() != "" || nilSet.String() != "" {
+		t.Error("String of a zero or nil Set is not empty")
+	}
+
+	buffered := newClientSet(t, client, "buffered", WithWriteBuffer(10, time.Hour))
+	buffered.Set("x,y")
+	if n := f.count("sadd"); n != 1 {
+		t.Errorf("Set sent %d SADDs despite WithWriteBuffer", n-1)
+	}
+	if ok, _ := buffered.Has("x"); !ok {
+		t.Error("Has(x) = false for a queued member")
+	}
+}
+
+func TestInsertMany(t *testing.T) {
+	client, f, server := newFaultyClient(t)
+	s := newClientSet(t, client, "many")
+	if err := s.InsertMany(); err != nil {
+		t.Errorf("InsertMany() = %v", err)
+	}
+	if n := f.count("sadd"); n != 0 || server.Exists("many") {
+		t.Errorf("InsertMany() sent %d SADDs, want none and no key", n)
+	}
+	if err := s.InsertMany("a", "B", "a", "c"); err != nil {
+		t.Fatal(err)
+	}
+	if n := f.count("sadd"); n != 1 {
+		t.Errorf("InsertMany sent %d SADDs, want one variadic SADD", n)
+	}
+	if got := mustMembers(t, server, "many"); !slices.Equal(got, []string{"a", "b", "c"}) {
+		t.Errorf("members = %v, want [a b c]", got)
+	}
+}
+
+// BenchmarkInsertMany compares inserting 10000 elements one Insert at a time
+// with a single InsertMany.
+func BenchmarkInsertMany(b *testing.B) {
+	elements := make([]string, 10000)
+	for i := range elements {
+		elements[i] = fmt.Sprintf("member-%d", i)
+	}
+	for _, bb := range []struct {
+		name string
+		run  func(s *Set) error
+	}{
+		{"per-element", func(s *Set) error {
+			for _, element := range elements {
+				if err := s.Insert(element); err != nil {
+					return err
+				}
+			}
+			return nil
+		}},
+		{"variadic", func(s *Set) error { return s.InsertMany(elements...) }},
+	} {
+		b.Run(bb.name, func(b *testing.B) {
+			s, _ := newTestSet(b, "bench")
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				if err := s.Clear(); err != nil {
+					b.Fatal(err)
+				}
+				b.StartTimer()
+				if err := bb.run(s); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// cancelAfter is a go-redis hook cancelling a context once n SADDs, or
+// pipelines starting with one, have been sent.
+type cancelAfter struct {
+	n      atomic.Int64
+	cancel context.CancelFunc
+}
+
+func (h *cancelAfter) DialHook(next redis.DialHook) redis.DialHook { return next }
+
+func (h *cancelAfter) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		err := next(ctx, cmd)
+		if cmd.Name() == "sadd" && h.n.Add(-1) == 0 {
+			h.cancel()
+		}
+		return err
+	}
+}
+
+func (h *cancelAfter) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		err := next(ctx, cmds)
+		if len(cmds) > 0 && cmds[0].Name() == "sadd" && h.n.Add(-1) == 0 {
+			h.cancel()
+		}
+		return err
+	}
+}
+
+func TestInsertManyInBatches(t *testing.T) {
+	elements := make([]string, 120000)
+	for i := range elements {
+		elements[i] = fmt.Sprintf("member-%d", i)
+	}
+	client, f, server := newFaultyClient(t)
+	s := newClientSet(t, client, "large")
+	if err := s.InsertMany(elements...); err != nil {
+		t.Fatal(err)
+	}
+	if n := f.count("sadd"); n != 24 {
+		t.Errorf("sent %d SADDs, want 24 of the default 5000 members", n)
+	}
+	got := mustMembers(t, server, "large")
+	slices.Sort(elements)
+	if !slices.Equal(got, elements) {
+		t.Errorf("stored %d members, want all %d", len(got), len(elements))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	hook := &cancelAfter{cancel: cancel}
+	hook.n.Store(2)
+	client.AddHook(hook)
+	small := newClientSet(t, client, "small", WithBatchSize(1000))
+	err := small.InsertManyCtx(ctx, elements[:10000]...)
+	var batchErr *BatchError
+	if !errors.As(err, &batchErr) || !errors.Is(err, context.Canceled) {
+		t.Fatalf("InsertManyCtx cancelled between batches = %v, want a *BatchError wrapping context.Canceled", err)
+	}
+	if batchErr.Processed != 2000 {
+		t.Errorf("Processed = %d, want the 2000 members of two batches", batchErr.Processed)
+	}
+	if got := mustMembers(t, server, "small"); len(got) != 2000 {
+		t.Errorf("stored %d members, want 2000", len(got))
+	}
+}
+
+// BenchmarkInsertManyBatchSize measures the throughput of inserting 100000
+// elements for several batch sizes.
+func BenchmarkInsertManyBatchSize(b *testing.B) {
+	elements := make([]string, 100000)
+	for i := range elements {
+		elements[i] = fmt.Sprintf("member-%d", i)
+	}
+	for _, size := range []int{500, 5000, 50000} {
+		b.Run(fmt.Sprint(size), func(b *testing.B) {
+			s, _ := newTestSet(b, "bench", WithBatchSize(size))
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				if err := s.Clear(); err != nil {
+					b.Fatal(err)
+				}
+				b.StartTimer()
+				if err := s.InsertMany(elements...); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.ReportMetric(float64(len(elements)*b.N)/b.Elapsed().Seconds(), "members/s")
+		})
+	}
+}
+
+// BenchmarkDeduplicate compares Deduplicate on 100000 elements, half of them
+// duplicates, with sending one SADD per element before the SMEMBERS.
+func BenchmarkDeduplicate(b *testing.B) {
+	input := make([]string, 100000)
+	for i := range input {
+		input[i] = fmt.Sprintf("member-%d", i/2)
+	}
+	for _, bb := range []struct {
+		name string
+		run  func(client *redis.Client) ([]string, error)
+	}{
+		{"per-element", func(client *redis.Client) ([]string, error) {
+			ctx := context.Background()
+			for _, element := range input {
+				if err := client.SAdd(ctx, "scratch", strings.ToLower(element)).Err(); err != nil {
+					return nil, err
+				}
+			}
+			defer client.Del(ctx, "scratch")
+			return client.SMembers(ctx, "scratch").Result()
+		}},
+		{"pipelined", func(client *redis.Client) ([]string, error) {
+			return Deduplicate(client, "", input, WithLogger(nil))
+		}},
+	} {
+		b.Run(bb.name, func(b *testing.B) {
+			client, _ := newTestClient(b)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				result, err := bb.run(client)
+				if err != nil {
+					b.Fatal(err)
+				}
+				if len(result) != len(input)/2 {
+					b.Fatalf("got %d members, want %d", len(result), len(input)/2)
+				}
+			}
+		})
+	}
+}
+
+func TestRemoveMany(t *testing.T) {
+	client, f, server := newFaultyClient(t)
+	s := newClientSet(t, client, "many", WithBatchSize(2))
+	s.InsertMany("a", "b", "c", "d")
+	if n, err := s.RemoveMany(); n != 0 || err != nil || f.count("srem") != 0 {
+		t.Errorf("RemoveMany() = %d, %v after %d SREMs, want a no-op", n, err, f.count("srem"))
+	}
+	if n, err := s.RemoveMany("A", "x", "b", "B", "y"); n != 2 || err != nil {
+		t.Errorf("RemoveMany = %d, %v, want 2 of the present members", n, err)
+	}
+	if n := f.count("srem"); n != 3 {
+		t.Errorf("sent %d SREMs, want 3 of at most 2 members", n)
+	}
+	if got := mustMembers(t, server, "many"); !slices.Equal(got, []string{"c", "d"}) {
+		t.Errorf("members = %v, want [c d]", got)
+	}
+
+	buffered := newClientSet(t, client, "buffered", WithWriteBuffer(100, time.Hour))
+	buffered.InsertMany("p", "q")
+	if n, err := buffered.RemoveMany("p", "r"); n != 1 || err != nil {
+		t.Errorf("RemoveMany of a queued member = %d, %v, want 1", n, err)
+	}
+	if got := mustMembers(t, server, "buffered"); !slices.Equal(got, []string{"q"}) {
+		t.Errorf("buffered = %v, want the queue flushed first, leaving [q]", got)
+	}
+}
+
+func TestDeduplicateScratchKeys(t *testing.T) {
+	client, server := newTestClient(t)
+
+	// Two streams left open each hold a scratch key of their own.
+	ins := []chan string{make(chan string), make(chan string)}
+	outs := []chan string{make(chan string), make(chan string)}
+	errc := make(chan error, len(ins))
+	for i := range ins {
+		go func() { errc <- DeduplicateStream(context.Background(), client, "", ins[i], outs[i], WithLogger(nil)) }()
+		ins[i] <- "x"
+		<-outs[i]
+	}
+	keys := server.Keys()
+	if len(keys) != 2 || keys[0] == keys[1] {
+		t.Errorf("scratch keys = %v, want two distinct keys", keys)
+	}
+	for _, key := range keys {
+		if !strings.HasPrefix(key, tempKeyPrefix) {
+			t.Errorf("scratch key %q lacks the %q prefix", key, tempKeyPrefix)
+		}
+	}
+	for i := range ins {
+		close(ins[i])
+		for range outs[i] {
+		}
+		if err := <-errc; err != nil {
+			t.Error(err)
+		}
+	}
+	if keys := server.Keys(); len(keys) != 0 {
+		t.Errorf("keys left behind: %v", keys)
+	}
+
+	// Concurrent calls never see each other's elements.
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			input := make([]string, 200)
+			for j := range input {
+				input[j] = fmt.Sprintf("caller-%d-%d", i, j%100)
+			}
+			for range 10 {
+				got, err := DeduplicateOrdered(client, "", input, WithLogger(nil))
+				if err != nil || !slices.Equal(got, input[:100]) {
+					t.Errorf("caller %d got %d members, %v, want its own 100", i, len(got), err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if keys := server.Keys(); len(keys) != 0 {
+		t.Errorf("keys left behind: %v", keys)
+	}
+}
+
+func TestDeduplicateStream(t *testing.T) {
+	client, server := newTestClient(t)
+	in, out := make(chan string, 64), make(chan string, 64)
+	go func() {
+		defer close(in)
+		for i := 0; i < 100000; i++ {
+			in <- fmt.Sprintf("Value-%d", i/2)
+		}
+	}()
+	errc := make(chan error, 1)
+	go func() { errc <- DeduplicateStream(context.Background(), client, "", in, out, WithLogger(nil)) }()
+	n := 0
+	for member := range out {
+		if want := fmt.Sprintf("value-%d", n); member != want {
+			t.Fatalf("member %d = %q, want %q in input order", n, member, want)
+		}
+		n++
+	}
+	if err := <-errc; err != nil {
+		t.Fatal(err)
+	}
+	if n != 50000 {
+		t.Errorf("streamed %d members, want 50000", n)
+	}
+	if keys := server.Keys(); len(keys) != 0 {
+		t.Errorf("keys left behind: %v", keys)
+	}
+
+	// Cancellation ends the stream while in is still open.
+	ctx, cancel := context.WithCancel(context.Background())
+	in, out = make(chan string), make(chan string)
+	go func() { errc <- DeduplicateStream(ctx, client, "", in, out, WithLogger(nil)) }()
+	in <- "a"
+	<-out
+	cancel()
+	for range out {
+	}
+	if err := <-errc; !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled DeduplicateStream = %v, want context.Canceled", err)
+	}
+	if keys := server.Keys(); len(keys) != 0 {
+		t.Errorf("keys left behind after cancellation: %v", keys)
+	}
+}
+
+func TestSliceSorted(t *testing.T) {
+	s, _ := newTestSet(t, "sorted", CaseSensitive())
+	s.InsertMany("b", "10", "B", "9", "a", "100", "A-1")
+	want := []string{"10", "100", "9", "A-1", "B", "a", "b"}
+	for range 3 {
+		if got, err := s.SliceSorted(false); !slices.Equal(got, want) || err != nil {
+			t.Fatalf("SliceSorted(false) = %q, %v, want %q", got, err, want)
+		}
+	}
+	slices.Reverse(want)
+	if got, err := s.SliceSorted(true); !slices.Equal(got, want) || err != nil {
+		t.Errorf("SliceSorted(true) = %q, %v, want %q", got, err, want)
+	}
+
+	empty, _ := newTestSet(t, "empty")
+	if got, err := empty.SliceSorted(false); got == nil || len(got) != 0 || err != nil {
+		t.Errorf("SliceSorted of an empty Set = %#v, %v, want an empty slice", got, err)
+	}
+	if _, err := newDeadSet(t, "dead").SliceSorted(false); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("SliceSorted on a dead server = %v, want ErrUnavailable", err)
+	}
+}
+
+func TestToMap(t *testing.T) {
+	s, _ := newTestSet(t, "mapped")
+	s.InsertMany("a", "B")
+	if got, err := s.ToMap(); err != nil || len(got) != 2 || got["a"] != struct{}{} || got["b"] != struct{}{} {
+		t.Errorf("ToMap = %v, %v, want a and b", got, err)
+	}
+	if got, err := s.ToBoolMap(); err != nil || len(got) != 2 || !got["a"] || !got["b"] || got["c"] {
+		t.Errorf("ToBoolMap = %v, %v, want a and b", got, err)
+	}
+
+	empty, _ := newTestSet(t, "empty")
+	if got, err := empty.ToMap(); got == nil || len(got) != 0 || err != nil {
+		t.Errorf("ToMap of an empty Set = %#v, %v, want an empty map", got, err)
+	}
+	dead := newDeadSet(t, "dead")
+	if got, err := dead.ToBoolMap(); got != nil || !errors.Is(err, ErrUnavailable) {
+		t.Errorf("ToBoolMap on a dead server = %v, %v, want nil and ErrUnavailable", got, err)
+	}
+}
+
+func TestIsEmpty(t *testing.T) {
+	client, f, server := newFaultyClient(t)
+	s := newClientSet(t, client, "maybe")
+	if empty, err := s.IsEmpty(); !empty || err != nil {
+		t.Errorf("IsEmpty of a missing key = %v, %v, want true", empty, err)
+	}
+	s.Insert("a")
+	if empty, err := s.IsEmpty(); empty || err != nil {
+		t.Errorf("IsEmpty with a member = %v, %v, want false", empty, err)
+	}
+	if n := f.count("smembers"); n != 0 {
+		t.Errorf("IsEmpty sent %d SMEMBERS, want SCARD only", n)
+	}
+	s.Remove("a")
+	if empty, err := s.IsEmpty(); !empty || err != nil {
+		t.Errorf("IsEmpty after removing the last member = %v, %v, want true", empty, err)
+	}
+
+	server.Set("maybe", "string")
+	if empty, err := s.IsEmpty(); empty || !errors.Is(err, ErrWrongType) {
+		t.Errorf("IsEmpty of a string key = %v, %v, want ErrWrongType", empty, err)
+	}
+	if empty, err := newDeadSet(t, "dead").IsEmpty(); empty || !errors.Is(err, ErrUnavailable) {
+		t.Errorf("IsEmpty on a dead server = %v, %v, want false and ErrUnavailable", empty, err)
+	}
+}
+
+func TestClear(t *testing.T) {
+	client, server := newTestClient(t)
+	s := newClientSet(t, client, "cleared")
+	s.InsertMany("a", "b")
+	if err := s.Clear(); err != nil {
+		t.Fatal(err)
+	}
+	if server.Exists("cleared") {
+		t.Error("key survived Clear")
+	}
+	if err := s.Clear(); err != nil {
+		t.Errorf("Clear of an empty Set = %v", err)
+	}
+	s.Insert("c")
+	if got := mustMembers(t, server, "cleared"); !slices.Equal(got, []string{"c"}) {
+		t.Errorf("members after Clear and Insert = %v, want [c]", got)
+	}
+
+	buffered := newClientSet(t, client, "buffered", WithWriteBuffer(10, time.Hour))
+	buffered.Insert("queued")
+	if err := buffered.Clear(); err != nil {
+		t.Fatal(err)
+	}
+	if err := buffered.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if server.Exists("buffered") {
+		t.Error("a write queued before Clear was flushed after it")
+	}
+	if err := newDeadSet(t, "dead").Clear(); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("Clear on a dead server = %v, want ErrUnavailable", err)
+	}
+}
+
+func TestSliceAndClear(t *testing.T) {
+	s, server := newTestSet(t, "drained", WithScanThreshold(0))
+	s.InsertMany("a", "b")
+	got, err := s.SliceAndClear()
+	slices.Sort(got)
+	if !slices.Equal(got, []string{"a", "b"}) || err != nil {
+		t.Errorf("SliceAndClear = %q, %v, want [a b]", got, err)
+	}
+	if server.Exists("drained") {
+		t.Error("key survived SliceAndClear")
+	}
+	if got, err := s.SliceAndClear(); got == nil || len(got) != 0 || err != nil {
+		t.Errorf("SliceAndClear of an empty Set = %#v, %v, want an empty slice", got, err)
+	}
+	// miniredis reports errors raised in scripts as compile errors, so only
+	// the failure itself is checked.
+	server.Set("drained", "string")
+	if _, err := s.SliceAndClear(); err == nil || !server.Exists("drained") {
+		t.Errorf("SliceAndClear of a string key = %v, want an error and the key kept", err)
+	}
+}
+
+// TestSliceAndClearWhileInserting drains a Set while others insert into it and
+// checks that every member ends up drained exactly once.
+func TestSliceAndClearWhileInserting(t *testing.T) {
+	s, _ := newTestSet(t, "drained")
+	const writers, perWriter = 4, 250
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < perWriter; j++ {
+				if err := s.Insert(fmt.Sprintf("w%d-%d", i, j)); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+	}
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	seen := make(map[string]int)
+	drain := func() {
+		got, err := s.SliceAndClear()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, member := range got {
+			seen[member]++
+		}
+	}
+	for running := true; running; {
+		select {
+		case <-done:
+			running = false
+		default:
+		}
+		drain()
+	}
+	drain()
+	if len(seen) != writers*perWriter {
+		t.Errorf("drained %d distinct members, want %d", len(seen), writers*perWriter)
+	}
+	for member, n := range seen {
+		if n != 1 {
+			t.Errorf("drained %s %d times", member, n)
+		}
+	}
+}
+
+func TestKeyAndClient(t *testing.T) {
+	client, server := newTestClient(t)
+	s := newClientSet(t, client, "accessed")
+	if s.Key() != "accessed" || s.Client() != client {
+		t.Errorf("Key, Client = %q, %v, want accessed and the client given", s.Key(), s.Client())
+	}
+	// Commands the Set does not wrap can be sent against Key.
+	s.Insert("a")
+	if err := s.Client().SAdd(context.Background(), s.Key(), "b").Err(); err != nil {
+		t.Fatal(err)
+	}
+	if got := mustMembers(t, server, "accessed"); !slices.Equal(got, []string{"a", "b"}) {
+		t.Errorf("members = %v, want [a b]", got)
+	}
+	if err := s.Rename("renamed"); err != nil || s.Key() != "renamed" {
+		t.Errorf("Key after Rename = %q, %v, want renamed", s.Key(), err)
+	}
+	tagged := newClientSet(t, client, "tagged", WithHashTag("t"))
+	if tagged.Key() != "{t}:tagged" {
+		t.Errorf("Key with WithHashTag = %q, want {t}:tagged", tagged.Key())
+	}
+}
+
+func TestExists(t *testing.T) {
+	s, server := newTestSet(t, "maybe")
+	exists := func() bool {
+		t.Helper()
+		ok, err := s.Exists()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ok
+	}
+	if exists() {
+		t.Error("Exists before the first Insert = true")
+	}
+	s.Insert("a")
+	if !exists() {
+		t.Error("Exists after Insert = false")
+	}
+	s.Remove("a")
+	if exists() {
+		t.Error("Exists after removing the last member = true")
+	}
+	s.Insert("b")
+	s.Clear()
+	if exists() {
+		t.Error("Exists after Clear = true")
+	}
+	server.Set("maybe", "string")
+	if !exists() {
+		t.Error("Exists of a string key = false")
+	}
+	if _, err := newDeadSet(t, "dead").Exists(); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("Exists on a dead server = %v, want ErrUnavailable", err)
+	}
+}
+
+func TestFromSlice(t *testing.T) {
+	values := make([]string, 100000)
+	byMap := make(map[string]struct{}, len(values))
+	for i := range values {
+		values[i] = fmt.Sprintf("Member-%d", i)
+		byMap[values[i]] = struct{}{}
+	}
+	for name, load := range map[string]func(client *redis.Client) (*Set, error){
+		"FromSlice": func(client *redis.Client) (*Set, error) {
+			return FromSlice(client, "loaded", values, WithLogger(nil))
+		},
+		"FromMap": func(client *redis.Client) (*Set, error) {
+			return FromMap(client, "loaded", byMap, WithLogger(nil))
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			client, server := newTestClient(t)
+			server.SAdd("loaded", "kept")
+			s, err := load(client)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n, err := s.Len(); n != len(values)+1 || err != nil {
+				t.Errorf("Len = %d, %v, want %d", n, err, len(values)+1)
+			}
+			for _, element := range []string{"Member-0", "member-4242", "MEMBER-99999", "kept"} {
+				if ok, err := s.Has(element); !ok || err != nil {
+					t.Errorf("Has(%s) = %v, %v, want true", element, ok, err)
+				}
+			}
+			if ok, _ := s.Has("member-100000"); ok {
+				t.Error("Has(member-100000) = true")
+			}
+		})
+	}
+
+	client, f, _ := newFaultyClient(t)
+	f.inject("sadd", 1, errRefused)
+	var batchErr *BatchError
+	if _, err := FromSlice(client, "failed", values[:10], WithLogger(nil)); !errors.As(err, &batchErr) || !errors.Is(err, ErrUnavailable) {
+		t.Errorf("FromSlice with a failing SADD = %v, want a *BatchError wrapping ErrUnavailable", err)
+	}
+}
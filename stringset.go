@@ -0,0 +1,31 @@
+package redisstringset
+
+// StringSet is the core method set of a set of strings, satisfied by *Set, for
+// code that should not depend on the Redis-backed implementation. It is kept
+// deliberately small; methods may be added in a new interface rather than to
+// this one, so that existing implementations keep satisfying it.
+type StringSet interface {
+	Insert(element string) error
+	InsertMany(elements ...string) error
+	Remove(element string) error
+	Has(element string) (bool, error)
+	Slice() ([]string, error)
+	Len() (int, error)
+	Close() error
+}
+
+var _ StringSet = (*Set)(nil)
+
+// DeduplicateInto is the StringSet counterpart of Deduplicate: it inserts
+// input into set and returns set's members, each once, so that code written
+// against StringSet can deduplicate with a Set, a LocalSet or a fake alike.
+// Elements are normalized by set. The members set already held are part of
+// the result, the result is in the order set's Slice returns, and set is
+// neither cleared nor closed afterwards; for a throwaway Redis key, which is
+// deleted even on failure, use Deduplicate.
+func DeduplicateInto(set StringSet, input []string) ([]string, error) {
+	if err := set.InsertMany(input...); err != nil {
+		return nil, err
+	}
+	return set.Slice()
+}
@@ -0,0 +1,114 @@
+package redisstringset
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+// fakeSet is the trivial StringSet a caller might write for its own tests.
+type fakeSet map[string]bool
+
+func (f fakeSet) Insert(element string) error { f[element] = true; return nil }
+
+func (f fakeSet) InsertMany(elements ...string) error {
+	for _, element := range elements {
+		f[element] = true
+	}
+	return nil
+}
+
+func (f fakeSet) Remove(element string) error { delete(f, element); return nil }
+
+func (f fakeSet) Has(element string) (bool, error) { return f[element], nil }
+
+func (f fakeSet) Slice() ([]string, error) {
+	result := []string{}
+	for element := range f {
+		result = append(result, element)
+	}
+	return result, nil
+}
+
+func (f fakeSet) Len() (int, error) { return len(f), nil }
+
+func (f fakeSet) Close() error { return nil }
+
+// countSeen is code written against StringSet: it inserts elements and
+// reports how many distinct ones the set holds.
+func countSeen(set StringSet, elements ...string) (int, error) {
+	if err := set.InsertMany(elements...); err != nil {
+		return 0, err
+	}
+	return set.Len()
+}
+
+func TestStringSetImplementations(t *testing.T) {
+	redisSet, _ := newTestSet(t, "implemented")
+	for _, tt := range []struct {
+		name string
+		set  StringSet
+	}{
+		{"Set", redisSet},
+		{"LocalSet", NewLocal()},
+		{"fake", fakeSet{}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			set := tt.set
+			if n, err := countSeen(set, "a", "b", "a"); n != 2 || err != nil {
+				t.Fatalf("countSeen = %d, %v, want 2", n, err)
+			}
+			if err := set.Insert("c"); err != nil {
+				t.Fatal(err)
+			}
+			if err := set.Remove("a"); err != nil {
+				t.Fatal(err)
+			}
+			if ok, err := set.Has("a"); ok || err != nil {
+				t.Errorf("Has(a) after Remove = %v, %v", ok, err)
+			}
+			if ok, err := set.Has("c"); !ok || err != nil {
+				t.Errorf("Has(c) = %v, %v", ok, err)
+			}
+			got, err := set.Slice()
+			if err != nil {
+				t.Fatal(err)
+			}
+			slices.Sort(got)
+			if want := []string{"b", "c"}; !slices.Equal(got, want) {
+				t.Errorf("Slice = %v, want %v", got, want)
+			}
+			if err := set.Close(); err != nil {
+				t.Errorf("Close: %v", err)
+			}
+		})
+	}
+}
+
+func TestDeduplicateInto(t *testing.T) {
+	redisSet, _ := newTestSet(t, "deduplicated")
+	redisSet.Insert("kept")
+	for _, tt := range []struct {
+		name string
+		set  StringSet
+		want []string
+	}{
+		{"Set", redisSet, []string{"a", "b", "kept"}},
+		{"LocalSet", NewLocal("kept"), []string{"a", "b", "kept"}},
+		// A fake keeps whatever it is given, case included.
+		{"fake", fakeSet{"kept": true}, []string{"A", "a", "b", "kept"}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DeduplicateInto(tt.set, []string{"a", "b", "A", "a"})
+			slices.Sort(got)
+			if !slices.Equal(got, tt.want) || err != nil {
+				t.Errorf("DeduplicateInto = %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+
+	strict := NewLocalWithOptions(RejectEmpty())
+	if got, err := DeduplicateInto(strict, []string{"a", ""}); got != nil || !errors.Is(err, ErrEmptyElement) {
+		t.Errorf("DeduplicateInto with a rejected element = %q, %v, want ErrEmptyElement", got, err)
+	}
+}
@@ -0,0 +1,100 @@
+package redisstringset
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Results of TTL that are not remaining lifetimes. They are negative, so any
+// positive result is a real TTL.
+const (
+	// NoExpiry reports that the Set's key exists but never expires.
+	NoExpiry time.Duration = -1
+	// NoKey reports that the Set's key does not exist, as for an empty Set.
+	NoKey time.Duration = -2
+)
+
+// WithIdleTTL makes the Set's key expire once it has gone d without being
+// written to. Every command modifying the Set, such as Insert, Remove, Pop,
+// the bulk inserts and the STORE commands behind Union, is pipelined with a
+// PEXPIRE of d, so the refresh costs no extra round trip; reads do not refresh
+// the TTL. MoveTo and Swap refresh both keys, each with its own Set's idle
+// TTL. Persist removes the TTL only until the next write. A zero or
+// negative d disables the refresh, which is the default.
+func WithIdleTTL(d time.Duration) Option {
+	return func(s *Set) {
+		s.idleTTL = d
+	}
+}
+
+// Expire makes Redis delete the Set's key after ttl, with millisecond
+// precision, so that an abandoned Set does not outlive its owner. Any write
+// that recreates the key after it expired starts without a TTL. Expire has no
+// effect if the key does not exist, that is if the Set is empty. As in Redis, a
+// ttl of zero or less deletes the key at once.
+func (s *Set) Expire(ttl time.Duration) error {
+	return s.ExpireCtx(context.Background(), ttl)
+}
+
+// ExpireCtx is like Expire but uses ctx for the Redis command.
+func (s *Set) ExpireCtx(ctx context.Context, ttl time.Duration) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	if err := s.redisClient.PExpire(ctx, s.key, ttl).Err(); err != nil {
+		s.logger.Printf("Error setting expiry of %s: %v", s.key, err)
+		return s.fail(fmt.Errorf("setting expiry of %s: %w", s.key, classify(err)))
+	}
+	return nil
+}
+
+// TTL returns the remaining lifetime of the Set's key, NoExpiry if it does not
+// expire or NoKey if it does not exist.
+func (s *Set) TTL() (time.Duration, error) {
+	return s.TTLCtx(context.Background())
+}
+
+// TTLCtx is like TTL but uses ctx for the Redis command.
+func (s *Set) TTLCtx(ctx context.Context) (time.Duration, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	ttl, err := s.redisClient.PTTL(ctx, s.key).Result()
+	if err != nil {
+		s.logger.Printf("Error getting expiry of %s: %v", s.key, err)
+		return 0, s.fail(fmt.Errorf("getting expiry of %s: %w", s.key, classify(err)))
+	}
+	// go-redis passes PTTL's special replies through unscaled.
+	switch ttl {
+	case -1:
+		return NoExpiry, nil
+	case -2:
+		return NoKey, nil
+	}
+	return ttl, nil
+}
+
+// Persist removes any expiry from the Set's key. It has no effect if the key
+// does not exist or does not expire.
+func (s *Set) Persist() error {
+	return s.PersistCtx(context.Background())
+}
+
+// PersistCtx is like Persist but uses ctx for the Redis command.
+func (s *Set) PersistCtx(ctx context.Context) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	if err := s.redisClient.Persist(ctx, s.key).Err(); err != nil {
+		s.logger.Printf("Error removing expiry of %s: %v", s.key, err)
+		return s.fail(fmt.Errorf("removing expiry of %s: %w", s.key, classify(err)))
+	}
+	return nil
+}
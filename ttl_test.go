@@ -0,0 +1,122 @@
+package redisstringset
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIdleTTL(t *testing.T) {
+	const idle = time.Minute
+	tests := []struct {
+		name string
+		// op writes to src, and dst if it needs a second Set.
+		op func(src, dst *Set) error
+		// keys are the keys whose TTL op must refresh.
+		keys []string
+	}{
+		{"Insert", func(src, _ *Set) error { return src.Insert("c") }, []string{"src"}},
+		{"InsertMany", func(src, _ *Set) error { return src.InsertMany("c", "d") }, []string{"src"}},
+		{"Remove", func(src, _ *Set) error { return src.Remove("a") }, []string{"src"}},
+		{"Pop", func(src, _ *Set) error { _, _, err := src.Pop(); return err }, []string{"src"}},
+		{"PopN", func(src, _ *Set) error { _, err := src.PopN(1); return err }, []string{"src"}},
+		{"MoveTo", func(src, dst *Set) error { _, err := src.MoveTo(dst, "a"); return err }, []string{"src", "dst"}},
+		{"Swap", func(src, dst *Set) error { return src.Swap(dst) }, []string{"src", "dst"}},
+		{"Union", func(src, dst *Set) error { return src.Union(dst) }, []string{"src"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, server := newTestClient(t)
+			src := newClientSet(t, client, "src", WithIdleTTL(idle))
+			dst := newClientSet(t, client, "dst", WithIdleTTL(idle))
+			server.SAdd("src", "a", "b")
+			server.SAdd("dst", "x", "y")
+			server.SetTTL("src", time.Second)
+			server.SetTTL("dst", time.Second)
+
+			if err := tt.op(src, dst); err != nil {
+				t.Fatal(err)
+			}
+			for _, key := range tt.keys {
+				if got := server.TTL(key); got != idle {
+					t.Errorf("TTL of %s = %v, want %v", key, got, idle)
+				}
+			}
+		})
+	}
+}
+
+func TestIdleTTLSlides(t *testing.T) {
+	s, server := newTestSet(t, "sliding", WithIdleTTL(time.Minute))
+	s.Insert("a")
+	server.FastForward(40 * time.Second)
+	s.Insert("b")
+	server.FastForward(40 * time.Second)
+	if !server.Exists("sliding") {
+		t.Fatal("key expired although written to within its idle TTL")
+	}
+	if got := server.TTL("sliding"); got != 20*time.Second {
+		t.Errorf("TTL = %v, want 20s", got)
+	}
+	s.Has("a")
+	if got := server.TTL("sliding"); got != 20*time.Second {
+		t.Errorf("TTL after a read = %v, want it unchanged at 20s", got)
+	}
+
+	plain, server := newTestSet(t, "plain")
+	plain.Insert("a")
+	if got := server.TTL("plain"); got != 0 {
+		t.Errorf("TTL without WithIdleTTL = %v, want none", got)
+	}
+}
+
+func TestExpire(t *testing.T) {
+	s, server := newTestSet(t, "expiring")
+	ttl := func() time.Duration {
+		t.Helper()
+		d, err := s.TTL()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return d
+	}
+	if got := ttl(); got != NoKey {
+		t.Errorf("TTL of a missing key = %v, want NoKey", got)
+	}
+	if err := s.Expire(time.Minute); err != nil || server.Exists("expiring") {
+		t.Errorf("Expire of an empty Set = %v, want no key created", err)
+	}
+	s.Insert("a")
+	if got := ttl(); got != NoExpiry {
+		t.Errorf("TTL of a new key = %v, want NoExpiry", got)
+	}
+	if err := s.Expire(1500 * time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if got := ttl(); got != 1500*time.Millisecond {
+		t.Errorf("TTL = %v, want 1.5s kept to the millisecond", got)
+	}
+	if err := s.Persist(); err != nil {
+		t.Fatal(err)
+	}
+	if got := ttl(); got != NoExpiry {
+		t.Errorf("TTL after Persist = %v, want NoExpiry", got)
+	}
+
+	s.Expire(time.Minute)
+	server.FastForward(time.Minute)
+	if ok, _ := s.Has("a"); ok {
+		t.Error("Has(a) = true after the Set expired")
+	}
+	if got := ttl(); got != NoKey {
+		t.Errorf("TTL after expiry = %v, want NoKey", got)
+	}
+
+	// A write after expiry recreates the key without a TTL.
+	s.Insert("b")
+	if got := ttl(); got != NoExpiry {
+		t.Errorf("TTL of the recreated key = %v, want NoExpiry", got)
+	}
+	if err := s.Expire(0); err != nil || server.Exists("expiring") {
+		t.Errorf("Expire(0) = %v, want the key deleted", err)
+	}
+}
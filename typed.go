@@ -0,0 +1,163 @@
+package redisstringset
+
+import (
+	"context"
+	"encoding"
+	"fmt"
+)
+
+// SetOf is a set of values of type T stored in a Set as their string
+// encodings. It converts values with the encode and decode functions given to
+// NewSetOf and otherwise behaves like the underlying Set, whose normalization
+// applies to the encoded strings: create the Set with CaseSensitive unless the
+// encoding survives lower-casing. SetOf is safe for concurrent use if encode
+// and decode are.
+type SetOf[T any] struct {
+	s      *Set
+	encode func(T) (string, error)
+	decode func(string) (T, error)
+}
+
+// NewSetOf returns a SetOf storing its values in s. decode must accept every
+// string encode produces after the Set's normalization.
+func NewSetOf[T any](s *Set, encode func(T) (string, error), decode func(string) (T, error)) *SetOf[T] {
+	return &SetOf[T]{s: s, encode: encode, decode: decode}
+}
+
+// NewTextSetOf is like NewSetOf but encodes values with their MarshalText
+// method and decodes them with UnmarshalText.
+func NewTextSetOf[T encoding.TextMarshaler, PT interface {
+	*T
+	encoding.TextUnmarshaler
+}](s *Set) *SetOf[T] {
+	return NewSetOf(s,
+		func(v T) (string, error) {
+			text, err := v.MarshalText()
+			return string(text), err
+		},
+		func(member string) (T, error) {
+			var v T
+			err := PT(&v).UnmarshalText([]byte(member))
+			return v, err
+		})
+}
+
+// Set returns the underlying Set.
+func (t *SetOf[T]) Set() *Set {
+	return t.s
+}
+
+// Insert adds v to the set.
+func (t *SetOf[T]) Insert(v T) error {
+	return t.InsertCtx(context.Background(), v)
+}
+
+// InsertCtx is like Insert but uses ctx for the Redis command.
+func (t *SetOf[T]) InsertCtx(ctx context.Context, v T) error {
+	element, err := t.encodeValue(v)
+	if err != nil {
+		return err
+	}
+	return t.s.InsertCtx(ctx, element)
+}
+
+// InsertMany adds all the values to the set, see Set.InsertMany. A value that
+// fails to encode fails the whole call before anything is inserted.
+func (t *SetOf[T]) InsertMany(values ...T) error {
+	return t.InsertManyCtx(context.Background(), values...)
+}
+
+// InsertManyCtx is like InsertMany but uses ctx for every Redis command.
+func (t *SetOf[T]) InsertManyCtx(ctx context.Context, values ...T) error {
+	elements, err := t.encodeValues(values)
+	if err != nil {
+		return err
+	}
+	return t.s.InsertManyCtx(ctx, elements...)
+}
+
+// Remove deletes v from the set.
+func (t *SetOf[T]) Remove(v T) error {
+	return t.RemoveCtx(context.Background(), v)
+}
+
+// RemoveCtx is like Remove but uses ctx for the Redis command.
+func (t *SetOf[T]) RemoveCtx(ctx context.Context, v T) error {
+	element, err := t.encodeValue(v)
+	if err != nil {
+		return err
+	}
+	return t.s.RemoveCtx(ctx, element)
+}
+
+// Has reports whether v is a member of the set.
+func (t *SetOf[T]) Has(v T) (bool, error) {
+	return t.HasCtx(context.Background(), v)
+}
+
+// HasCtx is like Has but uses ctx for the Redis command.
+func (t *SetOf[T]) HasCtx(ctx context.Context, v T) (bool, error) {
+	element, err := t.encodeValue(v)
+	if err != nil {
+		return false, err
+	}
+	return t.s.HasCtx(ctx, element)
+}
+
+// Slice returns the decoded members of the set. A member that fails to decode
+// fails the whole call.
+func (t *SetOf[T]) Slice() ([]T, error) {
+	return t.SliceCtx(context.Background())
+}
+
+// SliceCtx is like Slice but uses ctx for the Redis command.
+func (t *SetOf[T]) SliceCtx(ctx context.Context) ([]T, error) {
+	members, err := t.s.SliceCtx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]T, 0, len(members))
+	for _, member := range members {
+		v, err := t.decode(member)
+		if err != nil {
+			return nil, t.s.fail(fmt.Errorf("decoding member %q of %s: %w", member, t.s.lockedKey(), err))
+		}
+		result = append(result, v)
+	}
+	return result, nil
+}
+
+// Len returns the number of members in the set.
+func (t *SetOf[T]) Len() (int, error) {
+	return t.s.Len()
+}
+
+// LenCtx is like Len but uses ctx for the Redis command.
+func (t *SetOf[T]) LenCtx(ctx context.Context) (int, error) {
+	return t.s.LenCtx(ctx)
+}
+
+// Err returns the first error recorded by the underlying Set, see Set.Err.
+func (t *SetOf[T]) Err() error {
+	return t.s.Err()
+}
+
+func (t *SetOf[T]) encodeValue(v T) (string, error) {
+	element, err := t.encode(v)
+	if err != nil {
+		return "", t.s.fail(fmt.Errorf("encoding value for %s: %w", t.s.lockedKey(), err))
+	}
+	return element, nil
+}
+
+func (t *SetOf[T]) encodeValues(values []T) ([]string, error) {
+	elements := make([]string, 0, len(values))
+	for _, v := range values {
+		element, err := t.encodeValue(v)
+		if err != nil {
+			return nil, err
+		}
+		elements = append(elements, element)
+	}
+	return elements, nil
+}
@@ -0,0 +1,117 @@
+package redisstringset
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestSetOf(t *testing.T) {
+	s, server := newTestSet(t, "numbers")
+	numbers := NewSetOf(s,
+		func(v int64) (string, error) { return strconv.FormatInt(v, 10), nil },
+		func(member string) (int64, error) { return strconv.ParseInt(member, 10, 64) })
+	if numbers.Set() != s {
+		t.Error("Set does not return the underlying Set")
+	}
+
+	if err := numbers.InsertMany(3, -1, 3, 1<<40); err != nil {
+		t.Fatal(err)
+	}
+	if err := numbers.Insert(7); err != nil {
+		t.Fatal(err)
+	}
+	if err := numbers.Remove(3); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := numbers.Has(1 << 40); !ok || err != nil {
+		t.Errorf("Has(1<<40) = %v, %v", ok, err)
+	}
+	if ok, err := numbers.Has(3); ok || err != nil {
+		t.Errorf("Has(3) after Remove = %v, %v", ok, err)
+	}
+	got, err := numbers.Slice()
+	slices.Sort(got)
+	if want := []int64{-1, 7, 1 << 40}; !slices.Equal(got, want) || err != nil {
+		t.Errorf("Slice = %v, %v, want %v", got, err, want)
+	}
+	if n, err := numbers.Len(); n != 3 || err != nil {
+		t.Errorf("Len = %d, %v, want 3", n, err)
+	}
+	if members := mustMembers(t, server, "numbers"); !slices.Contains(members, "1099511627776") {
+		t.Errorf("stored members %q lack the decimal encoding", members)
+	}
+
+	server.SAdd("numbers", "seven")
+	if got, err := numbers.Slice(); got != nil || !errors.Is(err, strconv.ErrSyntax) {
+		t.Errorf("Slice with an undecodable member = %v, %v, want strconv.ErrSyntax", got, err)
+	}
+	if err := numbers.Err(); !errors.Is(err, strconv.ErrSyntax) {
+		t.Errorf("Err = %v, want the decoding error", err)
+	}
+}
+
+// label is a TextMarshaler whose encoding keeps its case and refuses an empty
+// name.
+type label struct {
+	Scope, Name string
+}
+
+var errNoName = errors.New("label without a name")
+
+func (l label) MarshalText() ([]byte, error) {
+	if l.Name == "" {
+		return nil, errNoName
+	}
+	return []byte(l.Scope + "/" + l.Name), nil
+}
+
+func (l *label) UnmarshalText(text []byte) error {
+	scope, name, ok := strings.Cut(string(text), "/")
+	if !ok {
+		return fmt.Errorf("label %q has no scope", text)
+	}
+	*l = label{Scope: scope, Name: name}
+	return nil
+}
+
+func TestNewTextSetOf(t *testing.T) {
+	client, f, server := newFaultyClient(t)
+	labels := NewTextSetOf[label](newClientSet(t, client, "labels", CaseSensitive()))
+
+	if err := labels.InsertMany(label{"team", "Go"}, label{"team", "go"}, label{"", "Misc"}); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := labels.Has(label{"team", "Go"}); !ok || err != nil {
+		t.Errorf("Has(team/Go) = %v, %v", ok, err)
+	}
+	got, err := labels.Slice()
+	slices.SortFunc(got, func(a, b label) int { return strings.Compare(a.Scope+a.Name, b.Scope+b.Name) })
+	if want := []label{{"", "Misc"}, {"team", "Go"}, {"team", "go"}}; !slices.Equal(got, want) || err != nil {
+		t.Errorf("Slice = %v, %v, want %v", got, err, want)
+	}
+	if members := mustMembers(t, server, "labels"); !slices.Contains(members, "team/Go") {
+		t.Errorf("stored members %q lack the MarshalText encoding", members)
+	}
+
+	// A value that fails to encode fails the whole call before Redis is asked.
+	sent := f.count("sadd")
+	if err := labels.InsertMany(label{"team", "Rust"}, label{"team", ""}); !errors.Is(err, errNoName) {
+		t.Errorf("InsertMany with an unnamed label = %v, want errNoName", err)
+	}
+	if n := f.count("sadd"); n != sent {
+		t.Errorf("failed InsertMany sent %d SADD", n-sent)
+	}
+	if ok, err := labels.Has(label{"team", ""}); ok || !errors.Is(err, errNoName) {
+		t.Errorf("Has of an unnamed label = %v, %v, want errNoName", ok, err)
+	}
+	if err := labels.Remove(label{"team", ""}); !errors.Is(err, errNoName) {
+		t.Errorf("Remove of an unnamed label = %v, want errNoName", err)
+	}
+	if n, err := labels.Len(); n != 3 || err != nil {
+		t.Errorf("Len = %d, %v, want 3", n, err)
+	}
+}